@@ -0,0 +1,269 @@
+package getter
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// objectsDir holds the cached files, named after the sha256 of their content.
+	objectsDir = "objects"
+
+	// sourcesDir holds the metadata of the cached sources, named after the
+	// sha1 of their URL.
+	sourcesDir = "sources"
+)
+
+// cacheEntry is the metadata recorded about a cached source.
+type cacheEntry struct {
+	// Source is the URL the files were downloaded from.
+	Source string `json:"source"`
+
+	// Files are the files of the source.
+	Files []cachedFile `json:"files"`
+
+	// FetchedAt is when the files were downloaded.
+	FetchedAt time.Time `json:"fetched_at"`
+
+	// Revision is the revision the files were downloaded at (e.g. a git
+	// commit), if the source has one.
+	Revision string `json:"revision,omitempty"`
+}
+
+// cachedFile is the metadata recorded about a file of a cached source.
+type cachedFile struct {
+	// Name is the path of the file in the source.
+	Name string `json:"name"`
+
+	// Hash is the sha256 checksum of the file content (e.g. "sha256:abcd...").
+	Hash string `json:"hash"`
+
+	// Object is the name of the file in the objects directory.
+	Object string `json:"object"`
+}
+
+// entryPath returns where the metadata of a source is stored.
+func entryPath(cacheDir string, source string) string {
+	h := sha1.New()
+	h.Write([]byte(source))
+
+	return filepath.Join(cacheDir, sourcesDir, hex.EncodeToString(h.Sum(nil))+".json")
+}
+
+// readEntry reads the metadata of a source, or returns nil if the source
+// is not cached.
+func readEntry(path string) *cacheEntry {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(content, &entry); err != nil || len(entry.Files) == 0 {
+		return nil
+	}
+
+	// The object names come from the disk, they must not escape the cache
+	for _, f := range entry.Files {
+		if f.Object == "" || f.Object != filepath.Base(f.Object) {
+			return nil
+		}
+	}
+
+	return &entry
+}
+
+// files returns the cached files of the entry, unless some of them were
+// removed from the cache.
+func (e *cacheEntry) files(cacheDir string) ([]File, bool) {
+	files := make([]File, 0, len(e.Files))
+	for _, f := range e.Files {
+		p := filepath.Join(cacheDir, objectsDir, f.Object)
+		if _, err := os.Stat(p); err != nil {
+			return nil, false
+		}
+
+		files = append(files, File{Name: f.Name, Path: p})
+	}
+
+	return files, true
+}
+
+// expired reports whether the entry is older than the given TTL. A TTL of
+// zero never expires.
+func (e *cacheEntry) expired(ttl time.Duration) bool {
+	return ttl > 0 && time.Since(e.FetchedAt) >= ttl
+}
+
+// store adds the given files to the cache and records that they were
+// downloaded from source, at the given revision. It returns the cached files.
+func store(cacheDir string, source string, revision string, files []File) ([]File, error) {
+	entry := &cacheEntry{
+		Source:    source,
+		FetchedAt: time.Now().UTC(),
+		Revision:  revision,
+	}
+
+	cached := make([]File, 0, len(files))
+	for _, f := range files {
+		// Local files are linked rather than copied, so this reads the actual content
+		content, err := os.ReadFile(f.Path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read downloaded file: %w", err)
+		}
+
+		sum := sha256.Sum256(content)
+		hash := hex.EncodeToString(sum[:])
+		object := hash + filepath.Ext(f.Name)
+
+		dst := filepath.Join(cacheDir, objectsDir, object)
+		if err := writeFileAtomic(dst, content); err != nil {
+			return nil, fmt.Errorf("could not write cached file: %w", err)
+		}
+
+		entry.Files = append(entry.Files, cachedFile{Name: f.Name, Hash: "sha256:" + hash, Object: object})
+		cached = append(cached, File{Name: f.Name, Path: dst})
+	}
+
+	metadata, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("could not serialize cache metadata: %w", err)
+	}
+
+	if err := writeFileAtomic(entryPath(cacheDir, source), append(metadata, '\n')); err != nil {
+		return nil, fmt.Errorf("could not write cache metadata: %w", err)
+	}
+
+	return cached, nil
+}
+
+// touch marks a cached file as used, so it is evicted last.
+func touch(path string) {
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+}
+
+// EvictCache removes the least recently used files from the cache until its
+// size is below maxSize (zero means the cache is not bounded).
+//
+// It is meant to be called once every source of a run was fetched, with all
+// the fetched files to keep, so that a fetch never removes the files another
+// one returned before they are read.
+func EvictCache(cacheDir string, maxSize int64, keep []File) error {
+	if maxSize <= 0 {
+		return nil
+	}
+
+	if err := evict(cacheDir, maxSize, keep); err != nil {
+		return fmt.Errorf("could not evict cached files: %w", err)
+	}
+
+	return nil
+}
+
+// evict removes the least recently used files from the cache until its
+// size is below maxSize. The files to keep are never removed.
+func evict(cacheDir string, maxSize int64, keep []File) error {
+	dir := filepath.Join(cacheDir, objectsDir)
+
+	kept := make(map[string]struct{}, len(keep))
+	for _, f := range keep {
+		kept[f.Path] = struct{}{}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	type object struct {
+		path   string
+		size   int64
+		usedAt time.Time
+	}
+
+	var (
+		objects []object
+		size    int64
+	)
+	for _, e := range entries {
+		// Files being written by another run are left alone
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		objects = append(objects, object{
+			path:   filepath.Join(dir, e.Name()),
+			size:   info.Size(),
+			usedAt: info.ModTime(),
+		})
+		size += info.Size()
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].usedAt.Before(objects[j].usedAt)
+	})
+
+	for _, o := range objects {
+		if size <= maxSize {
+			break
+		}
+
+		if _, ok := kept[o.path]; ok {
+			continue
+		}
+
+		// Another run might have removed it already
+		if err := os.Remove(o.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		size -= o.size
+	}
+
+	return nil
+}
+
+// writeFileAtomic writes a file through a temporary file renamed in place,
+// so that concurrent readers never see it half-written.
+func writeFileAtomic(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,251 @@
+package getter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchCache(t *testing.T) {
+	ctx := context.Background()
+
+	src := filepath.Join(t.TempDir(), "lib.js")
+	write := func(content string) {
+		if err := os.WriteFile(src, []byte(content), 0o600); err != nil {
+			t.Fatalf("could not write library: %v", err)
+		}
+	}
+	read := func(path string) string {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("could not read cached file: %v", err)
+		}
+		return string(content)
+	}
+
+	url := "file://" + src
+	cache := t.TempDir()
+
+	write("$(function f() { return 1; })")
+
+	dst, err := Fetch(ctx, &FetchInput{URL: url, Path: cache})
+	if err != nil {
+		t.Fatalf("fetch was not expected to fail: %v", err)
+	}
+
+	t.Run("Metadata", func(t *testing.T) {
+		if filepath.Ext(dst) != ".js" || filepath.Dir(dst) != filepath.Join(cache, objectsDir) {
+			t.Errorf("unexpected cached file: %s", dst)
+		}
+
+		content, err := os.ReadFile(entryPath(cache, url))
+		if err != nil {
+			t.Fatalf("could not read metadata: %v", err)
+		}
+
+		var entry cacheEntry
+		if err := json.Unmarshal(content, &entry); err != nil {
+			t.Fatalf("could not parse metadata: %v", err)
+		}
+
+		hash, _ := fileHash(dst)
+		if entry.Source != url || len(entry.Files) != 1 || entry.Files[0].Name != "lib.js" || entry.Files[0].Hash != hash || entry.FetchedAt.IsZero() {
+			t.Errorf("unexpected metadata: %+v", entry)
+		}
+
+		// The cached file is a copy, not a link to the source
+		if info, err := os.Lstat(dst); err != nil || !info.Mode().IsRegular() {
+			t.Errorf("the cached file was expected to be a regular file")
+		}
+	})
+
+	write("$(function f() { return 2; })")
+
+	t.Run("Cached", func(t *testing.T) {
+		cached, err := Fetch(ctx, &FetchInput{URL: url, Path: cache, TTL: time.Hour})
+		if err != nil {
+			t.Fatalf("fetch was not expected to fail: %v", err)
+		}
+
+		if cached != dst || read(cached) != "$(function f() { return 1; })" {
+			t.Errorf("the cached file was expected to be used, got: %s", read(cached))
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		fetched, err := Fetch(ctx, &FetchInput{URL: url, Path: cache, TTL: time.Nanosecond})
+		if err != nil {
+			t.Fatalf("fetch was not expected to fail: %v", err)
+		}
+
+		if read(fetched) != "$(function f() { return 2; })" {
+			t.Errorf("the file was expected to be downloaded again, got: %s", read(fetched))
+		}
+
+		// The previous content is still cached under its own hash
+		if read(dst) != "$(function f() { return 1; })" {
+			t.Errorf("the previous file was expected to be left untouched")
+		}
+	})
+
+	write("$(function f() { return 3; })")
+
+	t.Run("Refresh", func(t *testing.T) {
+		fetched, err := Fetch(ctx, &FetchInput{URL: url, Path: cache, Refresh: true})
+		if err != nil {
+			t.Fatalf("fetch was not expected to fail: %v", err)
+		}
+
+		if read(fetched) != "$(function f() { return 3; })" {
+			t.Errorf("the file was expected to be downloaded again, got: %s", read(fetched))
+		}
+	})
+
+	t.Run("No leftovers", func(t *testing.T) {
+		for _, dir := range []string{cache, filepath.Join(cache, objectsDir), filepath.Join(cache, sourcesDir)} {
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("could not read cache: %v", err)
+			}
+
+			for _, e := range entries {
+				if strings.HasPrefix(e.Name(), ".") {
+					t.Errorf("temporary file was left in the cache: %s", e.Name())
+				}
+			}
+		}
+	})
+}
+
+func TestFetchEviction(t *testing.T) {
+	ctx := context.Background()
+	cache := t.TempDir()
+
+	content := strings.Repeat("x", 100)
+	fetch := func(url string) string {
+		dst, err := Fetch(ctx, &FetchInput{URL: url, Path: cache})
+		if err != nil {
+			t.Fatalf("fetch was not expected to fail: %v", err)
+		}
+		return dst
+	}
+	exists := func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+
+	urlA, _ := writeLibrary(t, "a"+content)
+	urlB, _ := writeLibrary(t, "b"+content)
+	urlC, _ := writeLibrary(t, "c"+content)
+
+	a := fetch(urlA)
+	b := fetch(urlB)
+
+	// Use a again, so b is the least recently used
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(a, past, past); err != nil {
+		t.Fatalf("could not change times: %v", err)
+	}
+	if err := os.Chtimes(b, past, past); err != nil {
+		t.Fatalf("could not change times: %v", err)
+	}
+	fetch(urlA)
+
+	c := fetch(urlC)
+
+	// Nothing is evicted while fetching
+	if !exists(a) || !exists(b) || !exists(c) {
+		t.Fatalf("no file was expected to be evicted while fetching")
+	}
+
+	if err := EvictCache(cache, 250, []File{{Name: "c.js", Path: c}}); err != nil {
+		t.Fatalf("eviction was not expected to fail: %v", err)
+	}
+
+	if !exists(a) || exists(b) || !exists(c) {
+		t.Errorf("the least recently used file was expected to be evicted (a: %v, b: %v, c: %v)", exists(a), exists(b), exists(c))
+	}
+
+	// An evicted file is downloaded again
+	b = fetch(urlB)
+	if !exists(b) {
+		t.Errorf("the evicted file was expected to be downloaded again")
+	}
+
+	// The files of a run are kept, even if they do not fit in the cache
+	if err := EvictCache(cache, 1, []File{{Path: a}, {Path: b}, {Path: c}}); err != nil {
+		t.Fatalf("eviction was not expected to fail: %v", err)
+	}
+
+	if !exists(a) || !exists(b) || !exists(c) {
+		t.Errorf("the files to keep were not expected to be evicted (a: %v, b: %v, c: %v)", exists(a), exists(b), exists(c))
+	}
+}
+
+func TestFetchOffline(t *testing.T) {
+	ctx := context.Background()
+	cache := t.TempDir()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("$(function f() { return 1; })"))
+	}))
+	url := server.URL + "/lib.js"
+
+	t.Run("Not cached", func(t *testing.T) {
+		if _, err := Fetch(ctx, &FetchInput{URL: url, Path: cache, Offline: true}); !errors.Is(err, ErrNotCached) {
+			t.Errorf("fetch was expected to fail with a missing file, got: %v", err)
+		}
+	})
+
+	dst, err := Fetch(ctx, &FetchInput{URL: url, Path: cache})
+	if err != nil {
+		t.Fatalf("fetch was not expected to fail: %v", err)
+	}
+
+	// The network is gone from now on
+	server.Close()
+
+	t.Run("Cached", func(t *testing.T) {
+		cached, err := Fetch(ctx, &FetchInput{URL: url, Path: cache, Offline: true, TTL: time.Nanosecond, Refresh: true})
+		if err != nil {
+			t.Fatalf("fetch was not expected to fail: %v", err)
+		}
+
+		if cached != dst {
+			t.Errorf("the cached file was expected to be used, got: %s", cached)
+		}
+	})
+
+	t.Run("Altered cache", func(t *testing.T) {
+		_, other := writeLibrary(t, "$(function f() { return 2; })")
+
+		if _, err := Fetch(ctx, &FetchInput{URL: url, Checksum: other, Path: cache, Offline: true}); !errors.Is(err, ErrChecksumMismatch) {
+			t.Errorf("fetch was expected to fail with a checksum mismatch, got: %v", err)
+		}
+	})
+
+	t.Run("Evicted", func(t *testing.T) {
+		if err := os.Remove(dst); err != nil {
+			t.Fatalf("could not remove cached file: %v", err)
+		}
+
+		if _, err := Fetch(ctx, &FetchInput{URL: url, Path: cache, Offline: true}); !errors.Is(err, ErrNotCached) {
+			t.Errorf("fetch was expected to fail with a missing file, got: %v", err)
+		}
+	})
+
+	t.Run("Local file", func(t *testing.T) {
+		local, _ := writeLibrary(t, "$(function f() { return 3; })")
+
+		if _, err := Fetch(ctx, &FetchInput{URL: local, Path: cache, Offline: true}); err != nil {
+			t.Errorf("local files were expected to be read in offline mode: %v", err)
+		}
+	})
+}
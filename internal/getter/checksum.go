@@ -0,0 +1,110 @@
+package getter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"strings"
+)
+
+var (
+	ErrInvalidChecksum  = errors.New("invalid checksum")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+// checksum is the expected hash of a file.
+type checksum struct {
+	typ   string
+	hash  func() hash.Hash
+	value []byte
+}
+
+// parseChecksum parses a checksum in the `type:value` format (e.g. `sha256:abcd...`),
+// where the type is either sha256 or sha512. Weaker types that go-getter
+// accepts (md5 and sha1) are refused, since they cannot prevent tampering.
+//
+// Like go-getter does, the type can be left out, in which case it is
+// guessed from the length of the value.
+func parseChecksum(s string) (*checksum, error) {
+	typ, value, ok := strings.Cut(s, ":")
+	if !ok {
+		value = typ
+
+		switch len(value) {
+		case sha256.Size * 2:
+			typ = "sha256"
+		case sha512.Size * 2:
+			typ = "sha512"
+		default:
+			return nil, fmt.Errorf("%w: cannot guess the type of '%s'", ErrInvalidChecksum, s)
+		}
+	}
+
+	c := &checksum{typ: typ}
+	switch typ {
+	case "sha256":
+		c.hash = sha256.New
+	case "sha512":
+		c.hash = sha512.New
+	case "md5", "sha1":
+		return nil, fmt.Errorf("%w: %s is too weak to verify libraries (use sha256 or sha512)", ErrInvalidChecksum, typ)
+	default:
+		return nil, fmt.Errorf("%w: unsupported type '%s' (use sha256 or sha512)", ErrInvalidChecksum, typ)
+	}
+
+	decoded, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s' is not hex encoded", ErrInvalidChecksum, value)
+	}
+
+	if len(decoded) != c.hash().Size() {
+		return nil, fmt.Errorf("%w: a %s checksum must have %d characters", ErrInvalidChecksum, typ, c.hash().Size()*2)
+	}
+
+	c.value = decoded
+
+	return c, nil
+}
+
+// VerifyChecksum checks that the given content matches the checksum, in the
+// `type:value` format (e.g. `sha256:abcd...`).
+func VerifyChecksum(content []byte, checksum string) error {
+	c, err := parseChecksum(checksum)
+	if err != nil {
+		return err
+	}
+
+	return c.verifyContent(content)
+}
+
+// verify checks that the file at the given path matches the checksum.
+func (c *checksum) verify(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return c.verifyContent(content)
+}
+
+// verifyContent checks that the given content matches the checksum.
+func (c *checksum) verifyContent(content []byte) error {
+	h := c.hash()
+	h.Write(content)
+
+	if actual := h.Sum(nil); !bytes.Equal(actual, c.value) {
+		return fmt.Errorf(
+			"%w: expected %s:%s, got %s:%s",
+			ErrChecksumMismatch,
+			c.typ, hex.EncodeToString(c.value),
+			c.typ, hex.EncodeToString(actual),
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package getter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultParallelism = 4
+	defaultAttempts    = 3
+	defaultBackoff     = time.Second
+)
+
+// Fetcher downloads several sources at once.
+//
+// At most Parallelism sources are downloaded at the same time, identical
+// sources are only downloaded once, and temporary download failures (like
+// a network hiccup, or a server error) are retried with an exponential
+// backoff. Failures that downloading again cannot fix, like a missing file
+// or a checksum mismatch, are not retried.
+type Fetcher struct {
+	// Parallelism is the maximum number of sources downloaded at the same
+	// time. Zero means a default of 4.
+	Parallelism int
+
+	// Attempts is the maximum number of times a source is downloaded
+	// before giving up. Zero means a default of 3.
+	Attempts int
+
+	// Backoff is the delay before the first retry, which doubles for each
+	// following one. Zero means a default of 1s.
+	Backoff time.Duration
+
+	// Fetch downloads a source. If nil, FetchAll is used (e.g. it can be
+	// set to the FetchAll method of a Lock).
+	Fetch func(ctx context.Context, in *FetchInput) ([]File, error)
+}
+
+// FetchResult is the result of fetching a source.
+type FetchResult struct {
+	// Files are the fetched files, if the fetch succeeded.
+	Files []File
+
+	// Err is why the fetch failed.
+	Err error
+
+	// Attempts is the number of times the source was downloaded.
+	Attempts int
+
+	// Duration is how long fetching the source took, retries included.
+	Duration time.Duration
+}
+
+// FetchAll fetches all the given sources, and returns their results in the
+// same order. Once the context is done, the remaining sources are not
+// fetched.
+func (f *Fetcher) FetchAll(ctx context.Context, inputs []*FetchInput) []FetchResult {
+	parallelism := f.Parallelism
+	if parallelism <= 0 {
+		parallelism = defaultParallelism
+	}
+
+	// Identical sources are fetched once, and share their result
+	unique := make(map[string]int)
+	targets := make([]int, len(inputs))
+	work := make([]*FetchInput, 0, len(inputs))
+	for i, in := range inputs {
+		key := fetchKey(in)
+		if j, ok := unique[key]; ok {
+			targets[i] = j
+			continue
+		}
+
+		unique[key] = len(work)
+		targets[i] = len(work)
+		work = append(work, in)
+	}
+
+	fetched := make([]FetchResult, len(work))
+
+	// Sources are started in order, as soon as there is a free slot
+	sem := make(chan struct{}, parallelism)
+	wg := sync.WaitGroup{}
+	for i, in := range work {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+
+		if ctx.Err() != nil {
+			fetched[i] = FetchResult{Err: fmt.Errorf("download canceled: %w", ctx.Err())}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, in *FetchInput) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			fetched[i] = f.fetch(ctx, in)
+		}(i, in)
+	}
+	wg.Wait()
+
+	results := make([]FetchResult, len(inputs))
+	for i, j := range targets {
+		results[i] = fetched[j]
+	}
+
+	return results
+}
+
+// fetch fetches a single source, retrying temporary download failures.
+func (f *Fetcher) fetch(ctx context.Context, in *FetchInput) FetchResult {
+	fetch := f.Fetch
+	if fetch == nil {
+		fetch = FetchAll
+	}
+
+	attempts := f.Attempts
+	if attempts <= 0 {
+		attempts = defaultAttempts
+	}
+
+	backoff := f.Backoff
+	if backoff <= 0 {
+		backoff = defaultBackoff
+	}
+
+	start := time.Now()
+	result := FetchResult{}
+	for {
+		result.Attempts++
+		result.Files, result.Err = fetch(ctx, in)
+
+		if result.Err == nil || !errors.Is(result.Err, ErrTemporary) || result.Attempts >= attempts {
+			break
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+			backoff *= 2
+			continue
+		case <-ctx.Done():
+			timer.Stop()
+		}
+
+		break
+	}
+	result.Duration = time.Since(start)
+
+	return result
+}
+
+// fetchKey identifies what is fetched, so that identical sources are only
+// fetched once.
+func fetchKey(in *FetchInput) string {
+	return strings.Join([]string{in.URL, in.Checksum, in.Path, in.TTL.String()}, "\x00")
+}
@@ -0,0 +1,277 @@
+package getter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// libraryServer serves libraries named after their path, and counts the
+// downloads it receives (go-getter sends HEAD requests first, which are
+// answered but not counted).
+type libraryServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests map[string]int
+	active   int
+	peak     int
+
+	// failures is the number of times each path fails before being served,
+	// with the given status (503 by default)
+	failures int
+	status   int
+	delay    time.Duration
+}
+
+func newLibraryServer(t *testing.T) *libraryServer {
+	t.Helper()
+
+	s := &libraryServer{requests: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		count := s.requests[r.URL.Path]
+		s.active++
+		if s.active > s.peak {
+			s.peak = s.active
+		}
+		s.mu.Unlock()
+
+		defer func() {
+			s.mu.Lock()
+			s.active--
+			s.mu.Unlock()
+		}()
+
+		time.Sleep(s.delay)
+
+		if count <= s.failures {
+			status := s.status
+			if status == 0 {
+				status = http.StatusServiceUnavailable
+			}
+
+			w.WriteHeader(status)
+			return
+		}
+
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), ".js")
+		fmt.Fprintf(w, "$(function %s() { return 1; })", name)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *libraryServer) count(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[path]
+}
+
+func TestFetcher(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Parallel", func(t *testing.T) {
+		server := newLibraryServer(t)
+		server.delay = 50 * time.Millisecond
+
+		cache := t.TempDir()
+		inputs := make([]*FetchInput, 0)
+		for i := 0; i < 6; i++ {
+			inputs = append(inputs, &FetchInput{URL: fmt.Sprintf("%s/lib%d.js", server.URL, i), Path: cache})
+		}
+
+		results := (&Fetcher{Parallelism: 2}).FetchAll(ctx, inputs)
+
+		for i, r := range results {
+			if r.Err != nil {
+				t.Fatalf("fetch %d was not expected to fail: %v", i, r.Err)
+			}
+
+			if len(r.Files) != 1 || r.Files[0].Name != fmt.Sprintf("lib%d.js", i) {
+				t.Errorf("results were expected in the order of the inputs, got %v at %d", r.Files, i)
+			}
+
+			if r.Attempts != 1 || r.Duration <= 0 {
+				t.Errorf("unexpected result: %+v", r)
+			}
+		}
+
+		server.mu.Lock()
+		defer server.mu.Unlock()
+
+		if server.peak != 2 {
+			t.Errorf("2 downloads were expected at the same time, got %d", server.peak)
+		}
+	})
+
+	t.Run("Deduplication", func(t *testing.T) {
+		server := newLibraryServer(t)
+
+		cache := t.TempDir()
+		url := server.URL + "/lib.js"
+
+		results := (&Fetcher{}).FetchAll(ctx, []*FetchInput{
+			{URL: url, Path: cache},
+			{URL: server.URL + "/other.js", Path: cache},
+			{URL: url, Path: cache},
+		})
+
+		for i, r := range results {
+			if r.Err != nil {
+				t.Fatalf("fetch %d was not expected to fail: %v", i, r.Err)
+			}
+		}
+
+		if results[0].Files[0].Path != results[2].Files[0].Path {
+			t.Errorf("identical sources were expected to share their result")
+		}
+
+		if count := server.count("/lib.js"); count != 1 {
+			t.Errorf("identical sources were expected to be downloaded once, got %d requests", count)
+		}
+	})
+
+	t.Run("Retries", func(t *testing.T) {
+		server := newLibraryServer(t)
+		server.failures = 2
+
+		results := (&Fetcher{Backoff: time.Millisecond}).FetchAll(ctx, []*FetchInput{
+			{URL: server.URL + "/lib.js", Path: t.TempDir()},
+		})
+
+		if results[0].Err != nil {
+			t.Fatalf("fetch was expected to succeed after retries: %v", results[0].Err)
+		}
+
+		if results[0].Attempts != 3 {
+			t.Errorf("3 attempts were expected, got %d", results[0].Attempts)
+		}
+	})
+
+	t.Run("Too many failures", func(t *testing.T) {
+		server := newLibraryServer(t)
+		server.failures = 5
+
+		results := (&Fetcher{Attempts: 2, Backoff: time.Millisecond}).FetchAll(ctx, []*FetchInput{
+			{URL: server.URL + "/lib.js", Path: t.TempDir()},
+		})
+
+		if !errors.Is(results[0].Err, ErrDownloadFailed) || results[0].Attempts != 2 {
+			t.Errorf("fetch was expected to fail after 2 attempts, got %+v", results[0])
+		}
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		server := newLibraryServer(t)
+		server.failures = 5
+		server.status = http.StatusNotFound
+
+		results := (&Fetcher{Backoff: time.Millisecond}).FetchAll(ctx, []*FetchInput{
+			{URL: server.URL + "/lib.js", Path: t.TempDir()},
+		})
+
+		if !errors.Is(results[0].Err, ErrDownloadFailed) || errors.Is(results[0].Err, ErrTemporary) || results[0].Attempts != 1 {
+			t.Errorf("a missing library was not expected to be retried, got %+v", results[0])
+		}
+
+		if count := server.count("/lib.js"); count != 1 {
+			t.Errorf("a missing library was expected to be requested once, got %d requests", count)
+		}
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		results := (&Fetcher{Backoff: time.Hour}).FetchAll(ctx, []*FetchInput{
+			{URL: filepath.Join(t.TempDir(), "missing.js"), Path: t.TempDir()},
+		})
+
+		if results[0].Err == nil || results[0].Attempts != 1 {
+			t.Errorf("a missing local file was not expected to be retried, got %+v", results[0])
+		}
+	})
+
+	t.Run("Rate limited", func(t *testing.T) {
+		server := newLibraryServer(t)
+		server.failures = 1
+		server.status = http.StatusTooManyRequests
+
+		results := (&Fetcher{Backoff: time.Millisecond}).FetchAll(ctx, []*FetchInput{
+			{URL: server.URL + "/lib.js", Path: t.TempDir()},
+		})
+
+		if results[0].Err != nil || results[0].Attempts != 2 {
+			t.Errorf("a rate limited download was expected to be retried, got %+v", results[0])
+		}
+	})
+
+	t.Run("Permanent failure", func(t *testing.T) {
+		server := newLibraryServer(t)
+		_, other := writeLibrary(t, "$(function other() { return 1; })")
+
+		results := (&Fetcher{Backoff: time.Millisecond}).FetchAll(ctx, []*FetchInput{
+			{URL: server.URL + "/lib.js", Checksum: other, Path: t.TempDir()},
+		})
+
+		if !errors.Is(results[0].Err, ErrChecksumMismatch) || results[0].Attempts != 1 {
+			t.Errorf("a checksum mismatch was not expected to be retried, got %+v", results[0])
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		server := newLibraryServer(t)
+		server.failures = 5
+
+		ctx, cancel := context.WithCancel(ctx)
+
+		var calls atomic.Int32
+		fetcher := &Fetcher{
+			Parallelism: 1,
+			Backoff:     time.Hour,
+			Fetch: func(ctx context.Context, in *FetchInput) ([]File, error) {
+				// The context is canceled while the first source waits for a retry
+				if calls.Add(1) == 1 {
+					defer cancel()
+				}
+				return FetchAll(ctx, in)
+			},
+		}
+
+		done := make(chan []FetchResult)
+		go func() {
+			done <- fetcher.FetchAll(ctx, []*FetchInput{
+				{URL: server.URL + "/lib.js", Path: t.TempDir()},
+				{URL: server.URL + "/other.js", Path: t.TempDir()},
+			})
+		}()
+
+		select {
+		case results := <-done:
+			for i, r := range results {
+				if r.Err == nil {
+					t.Errorf("fetch %d was expected to fail", i)
+				}
+			}
+
+			if server.count("/other.js") != 0 {
+				t.Errorf("the remaining sources were not expected to be fetched")
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("fetch was expected to stop once canceled")
+		}
+	})
+}
@@ -2,113 +2,534 @@ package getter
 
 import (
 	"context"
-	"crypto/sha1"
-	"encoding/hex"
+	"errors"
 	"fmt"
+	"net"
 	"os"
-	"os/signal"
+	"os/exec"
+	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/hashicorp/go-getter"
-	urlhelper "github.com/hashicorp/go-getter/helper/url"
 )
 
+var (
+	ErrNotCached      = errors.New("file is not cached")
+	ErrNoMatch        = errors.New("no file matches the source")
+	ErrDownloadFailed = errors.New("could not download resource")
+
+	// ErrTemporary marks the download failures that might not happen again
+	// (e.g. a network hiccup, or a server that is overloaded), which are worth
+	// retrying.
+	ErrTemporary = errors.New("temporary failure")
+)
+
+// temporaryResponse matches the HTTP status codes go-getter reports that
+// might not be returned again: server errors and rate limiting.
+var temporaryResponse = regexp.MustCompile(`bad response code: (5\d\d|429)\b`)
+
+// temporaryNetworkErrors are the messages of the network errors, for the
+// go-getter failures that only keep the message of their cause.
+var temporaryNetworkErrors = []string{
+	"connection refused",
+	"connection reset",
+	"i/o timeout",
+	"TLS handshake timeout",
+	"unexpected EOF",
+}
+
+// File is a file fetched from a source.
+type File struct {
+	// Name is the path of the file in the source (e.g. "functions/lib.js").
+	Name string
+
+	// Path is the local copy of the file.
+	Path string
+}
+
 type FetchInput struct {
-	// URL represents the url from which the file should be downloaded.
+	// URL represents the url from which the files should be downloaded.
+	//
+	// It can point at a file, a directory or an archive, and select some of
+	// their files with a subdirectory or a glob (e.g. `./lib/*.js` or
+	// `git::https://github.com/org/repo.git//functions/*.js?ref=v2`).
 	URL string
 
-	// Checksum represents the checksum of the file to be checked against.
+	// Checksum represents the checksum of the file to be checked against,
+	// in the `type:value` format (e.g. `sha256:abcd...`).
+	//
+	// If set, the file is verified every time it is fetched, even when
+	// it is found in the cache. A checksum can only verify a source of a
+	// single file.
 	Checksum string
 
-	// Path represents the path where the file should be stored after it
-	// was downloaded.
+	// Path represents the directory where the downloaded files are cached.
 	Path string
+
+	// TTL is how long a cached file is used before it is downloaded again.
+	// Zero means it is never downloaded again.
+	TTL time.Duration
+
+	// Refresh forces the file to be downloaded, even if it is cached.
+	Refresh bool
+
+	// Offline prevents the file from being downloaded from the network: it
+	// is only resolved from the cache, whatever its TTL (or Refresh) is.
+	// Local files are still read, since they do not need the network.
+	Offline bool
+
+	// Mirrors are the local directories consulted before the cache and any
+	// remote source, even in offline mode.
+	Mirrors []Mirror
 }
 
-// Fetch downloads a file/directory from a given URL.
-//
-// It computes a hash of the source and then generates a key for the file.
-// If that exact key already exists in the destination path, the entire
-// download process is skipped.
-//
-// The method is not checking the file content, only its source and name.
+// Fetch downloads a single file like FetchAll does, and returns the path
+// of its local copy.
 func Fetch(ctx context.Context, in *FetchInput) (string, error) {
-	u, err := urlhelper.Parse(in.URL)
+	files, err := FetchAll(ctx, in)
 	if err != nil {
 		return "", err
 	}
 
-	// Set extra arguments
-	q := u.Query()
-	q.Add("archive", "false")
+	if len(files) != 1 {
+		return "", fmt.Errorf("%s was expected to be a single file, got %d files", in.URL, len(files))
+	}
+
+	return files[0].Path, nil
+}
+
+// FetchAll downloads the files of a source, which can be a file, a
+// directory or an archive, possibly narrowed down to a subdirectory or a
+// glob. Hidden files and directories (like `.git`) are left out.
+//
+// The files are cached by content: the cache records, for each URL, when
+// it was fetched and the hash of its files. If the URL was already
+// fetched and its TTL has not expired, the download is skipped.
+//
+// A cached file is not checked against its source, unless a checksum is
+// given. In that case, a cached file that does not match the checksum is
+// downloaded again, and a downloaded file that does not match the checksum
+// is not cached.
+//
+// Files found in one of the mirrors are read from there, and are not cached.
+//
+// In offline mode, a source that is not cached (or that does not match the
+// checksum anymore) cannot be fetched, and ErrNotCached is returned.
+func FetchAll(ctx context.Context, in *FetchInput) ([]File, error) {
+	files, _, err := fetchAll(ctx, in)
+	return files, err
+}
 
+// fetchAll fetches the files of a source like FetchAll does, and also
+// returns the revision they were fetched at (e.g. the commit a git ref
+// pointed at), if the source has one.
+func fetchAll(ctx context.Context, in *FetchInput) ([]File, string, error) {
+	var sum *checksum
 	if in.Checksum != "" {
-		q.Add("checksum", in.Checksum)
+		c, err := parseChecksum(in.Checksum)
+		if err != nil {
+			return nil, "", err
+		}
+		sum = c
+	}
+
+	verify := func(files []File) error {
+		if sum == nil {
+			return nil
+		}
+
+		if len(files) != 1 {
+			return fmt.Errorf("could not verify resource %s: %w: a checksum can only verify a single file, got %d files", in.URL, ErrChecksumMismatch, len(files))
+		}
+
+		if err := sum.verify(files[0].Path); err != nil {
+			return fmt.Errorf("could not verify resource %s: %w", in.URL, err)
+		}
+
+		return nil
+	}
+
+	if files, ok := findMirrored(in.Mirrors, in.URL); ok {
+		if err := verify(files); err != nil {
+			return nil, "", err
+		}
+
+		return files, "", nil
+	}
+
+	src, pattern := splitSource(in.URL)
+
+	if in.Offline && !isLocal(src) {
+		return fetchOffline(in, verify)
+	}
+
+	if !in.Refresh {
+		if entry := readEntry(entryPath(in.Path, in.URL)); entry != nil && !entry.expired(in.TTL) {
+			// Cached files that were altered cannot be trusted anymore, so
+			// they are downloaded again
+			if files, ok := entry.files(in.Path); ok && verify(files) == nil {
+				for _, f := range files {
+					touch(f.Path)
+				}
+
+				return files, entry.Revision, nil
+			}
+		}
+	}
+
+	root, cleanup, err := download(ctx, src, in.Path)
+	defer cleanup()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Local directories may be repositories too, but their working copy can
+	// differ from any revision
+	revision := ""
+	if !isLocal(src) {
+		if revision, err = resolveRevision(ctx, root); err != nil {
+			return nil, "", fmt.Errorf("could not resolve the revision of %s: %w", in.URL, err)
+		}
+	}
+
+	files, err := collect(root, pattern)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not find files in %s: %w", in.URL, err)
+	}
+
+	if err := verify(files); err != nil {
+		return nil, "", err
+	}
+
+	files, err = store(in.Path, in.URL, revision, files)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return files, revision, nil
+}
+
+// fetchOffline resolves the files of a source from the cache only.
+func fetchOffline(in *FetchInput, verify func([]File) error) ([]File, string, error) {
+	entry := readEntry(entryPath(in.Path, in.URL))
+	if entry == nil {
+		return nil, "", fmt.Errorf("%w: %s was never downloaded in %s and cannot be in offline mode", ErrNotCached, in.URL, in.Path)
+	}
+
+	files, ok := entry.files(in.Path)
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %s was removed from %s and cannot be downloaded again in offline mode", ErrNotCached, in.URL, in.Path)
+	}
+
+	if err := verify(files); err != nil {
+		return nil, "", err
+	}
+
+	for _, f := range files {
+		touch(f.Path)
+	}
+
+	return files, entry.Revision, nil
+}
+
+// splitSource splits a source into what is downloaded, and the subdirectory
+// or glob that selects some of its files.
+//
+// Like go-getter does, a subdirectory is given after a double slash (e.g.
+// `git::https://github.com/org/repo.git//functions/*.js`). Otherwise, the
+// path of the source can end with a glob (e.g. `./lib/*.js`).
+func splitSource(src string) (string, string) {
+	// go-getter reads "file://./lib" as a host, while it is a relative path
+	if strings.HasPrefix(src, "file://.") {
+		src = strings.TrimPrefix(src, "file://")
+	}
+
+	if base, subDir := getter.SourceDirSubdir(src); subDir != "" {
+		return base, subDir
+	}
+
+	p, query, hasQuery := strings.Cut(src, "?")
+
+	i := strings.IndexAny(p, "*[")
+	if i < 0 {
+		return src, ""
+	}
+
+	base, pattern := ".", p
+	if slash := strings.LastIndex(p[:i], "/"); slash >= 0 {
+		base, pattern = p[:slash], p[slash+1:]
+	}
+
+	if hasQuery {
+		base += "?" + query
+	}
+
+	return base, pattern
+}
+
+// collect lists the files found at root (a file or a directory) that match
+// the given subdirectory or glob. Directories are searched recursively.
+//
+// The files are named after their path in the selected directory (the
+// subdirectory, or the directory the glob starts from).
+func collect(root string, pattern string) ([]File, error) {
+	base, matches := root, []string{root}
+	if pattern != "" {
+		clean := filepath.Clean(filepath.FromSlash(pattern))
+		if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("the path '%s' is outside of the source", pattern)
+		}
+
+		base = filepath.Join(root, clean)
+		matches = []string{base}
+
+		if i := strings.IndexAny(clean, "*["); i >= 0 {
+			base = filepath.Join(root, filepath.Dir(clean[:i+1]))
+
+			globbed, err := filepath.Glob(filepath.Join(root, clean))
+			if err != nil {
+				return nil, fmt.Errorf("invalid glob '%s': %w", pattern, err)
+			}
+
+			// filepath.Glob matches hidden files, unlike shells, so they are skipped
+			matches = matches[:0]
+			for _, m := range globbed {
+				if !strings.HasPrefix(filepath.Base(m), ".") {
+					matches = append(matches, m)
+				}
+			}
+		}
+	}
+
+	var files []File
+	for _, m := range matches {
+		name, err := filepath.Rel(base, m)
+		if err != nil {
+			return nil, err
+		}
+		name = filepath.ToSlash(name)
+
+		info, err := os.Stat(m)
+		if err != nil {
+			continue
+		}
+
+		if info.Mode().IsRegular() {
+			if name == "." {
+				name = filepath.Base(m)
+			}
+
+			files = append(files, File{Name: name, Path: m})
+			continue
+		}
+
+		if !info.IsDir() {
+			continue
+		}
+
+		found, err := walk(m, name)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, found...)
+	}
+
+	if len(files) == 0 {
+		if pattern == "" {
+			return nil, ErrNoMatch
+		}
+
+		return nil, fmt.Errorf("%w: '%s'", ErrNoMatch, pattern)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+
+	return files, nil
+}
+
+// walk lists the regular files of a directory recursively, leaving out the
+// hidden ones. Their names are prefixed with the given one.
+func walk(dir string, prefix string) ([]File, error) {
+	if prefix == "." {
+		prefix = ""
+	}
+
+	// Local directories are linked rather than copied
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []File
+	err = filepath.WalkDir(real, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if p != real && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info, err := os.Stat(p); err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(real, p)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, File{Name: path.Join(prefix, filepath.ToSlash(rel)), Path: p})
+
+		return nil
+	})
+
+	return files, err
+}
+
+// isLocal reports whether the source is a file on the local filesystem.
+func isLocal(src string) bool {
+	pwd, err := os.Getwd()
+	if err != nil {
+		return false
 	}
 
-	u.RawQuery = q.Encode()
+	detected, err := getter.Detect(src, pwd, getter.Detectors)
+	if err != nil {
+		return false
+	}
 
-	filename := filepath.Base(u.Path)
-	ext := filepath.Ext(filename)
-	name := strings.TrimSuffix(filename, ext)
-	ext = strings.TrimPrefix(ext, ".")
+	return strings.HasPrefix(detected, "file://")
+}
 
-	// Compute the hash of the URL
-	h := sha1.New()
-	h.Write([]byte(in.URL))
-	hash := hex.EncodeToString(h.Sum(nil))
+// download fetches the source in a temporary directory of cacheDir, and
+// returns the path of the downloaded file or directory along with a
+// function removing it.
+//
+// Archives are unpacked, and relative sources are resolved from the
+// working directory. The download stops as soon as the context is done.
+func download(ctx context.Context, src string, cacheDir string) (string, func(), error) {
+	noop := func() {}
+
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		return "", noop, fmt.Errorf("could not create cache directory: %v", err)
+	}
 
-	key := fmt.Sprintf("%s.%s.%s", name, hash, ext)
-	dst := filepath.Join(in.Path, key)
+	tmp, err := os.MkdirTemp(cacheDir, ".download-*")
+	if err != nil {
+		return "", noop, fmt.Errorf("could not create download directory: %v", err)
+	}
+	cleanup := func() { _ = os.RemoveAll(tmp) }
 
-	if _, err := os.Stat(dst); err == nil {
-		// This exact file was already downloaded. We can skip the download.
-		return dst, nil
+	pwd, err := os.Getwd()
+	if err != nil {
+		return "", cleanup, fmt.Errorf("could not find working directory: %v", err)
 	}
 
-	// Configure the client
-	ctx, cancel := context.WithCancel(ctx)
+	dst := filepath.Join(tmp, "root")
+
 	client := &getter.Client{
 		Ctx:  ctx,
-		Src:  u.String(),
+		Src:  src,
 		Dst:  dst,
-		Pwd:  dst,
-		Mode: getter.ClientModeFile,
+		Pwd:  pwd,
+		Mode: getter.ClientModeAny,
+
+		Getters: newGetters(),
 	}
 
-	// Launch the download process
-	wg := sync.WaitGroup{}
-	wg.Add(1)
-	ech := make(chan error, 2)
-	go func() {
-		defer wg.Done()
-		defer cancel()
+	if err := client.Get(); err != nil {
+		// The download was interrupted on purpose, so it must not be retried
+		if ctx.Err() != nil {
+			return "", cleanup, fmt.Errorf("download canceled: %w", ctx.Err())
+		}
 
-		if err := client.Get(); err != nil {
-			ech <- err
+		if isTemporary(src, err) {
+			return "", cleanup, fmt.Errorf("%w: %w: %v", ErrDownloadFailed, ErrTemporary, err)
 		}
-	}()
 
-	sc := make(chan os.Signal, 1)
-	signal.Notify(sc, os.Interrupt)
+		return "", cleanup, fmt.Errorf("%w: %v", ErrDownloadFailed, err)
+	}
+
+	return dst, cleanup, nil
+}
 
-	// Wait for the download process to finish
-	select {
-	case sig := <-sc:
-		signal.Reset(os.Interrupt)
-		cancel()
-		wg.Wait()
+// resolveRevision returns the revision checked out in a downloaded
+// directory (the commit of a git or Mercurial clone), or an empty string
+// if the directory is not a repository.
+func resolveRevision(ctx context.Context, dir string) (string, error) {
+	var cmd *exec.Cmd
+	if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info.IsDir() {
+		cmd = exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
+	} else if info, err := os.Stat(filepath.Join(dir, ".hg")); err == nil && info.IsDir() {
+		cmd = exec.CommandContext(ctx, "hg", "id", "--debug", "--id")
+	} else {
+		return "", nil
+	}
 
-		return "", fmt.Errorf("download canceled: signal %v received", sig.String())
-	case err := <-ech:
-		wg.Wait()
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
 
-		return "", fmt.Errorf("could not download resource: %v", err)
-	case <-ctx.Done():
-		wg.Wait()
+	return strings.TrimSpace(string(out)), nil
+}
 
-		return dst, nil
+// newGetters returns the same getters as go-getter's defaults.
+//
+// The default getters are shared by all the clients, and remember the
+// client (and so the context) of the last download, which does not work
+// with concurrent downloads.
+func newGetters() map[string]getter.Getter {
+	httpGetter := &getter.HttpGetter{
+		Netrc: true,
 	}
+
+	return map[string]getter.Getter{
+		"file":  new(getter.FileGetter),
+		"git":   new(getter.GitGetter),
+		"gcs":   new(getter.GCSGetter),
+		"hg":    new(getter.HgGetter),
+		"s3":    new(getter.S3Getter),
+		"http":  httpGetter,
+		"https": httpGetter,
+	}
+}
+
+// isTemporary reports whether downloading the source failed because of
+// something that might not happen again. Local sources never fail
+// temporarily, and neither do missing files or unknown schemes.
+func isTemporary(src string, err error) bool {
+	if isLocal(src) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	msg := err.Error()
+	if temporaryResponse.MatchString(msg) {
+		return true
+	}
+
+	for _, m := range temporaryNetworkErrors {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,306 @@
+package getter
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeLibrary writes a library in a temporary directory and returns its
+// URL along with its sha256 checksum.
+func writeLibrary(t *testing.T, content string) (string, string) {
+	t.Helper()
+
+	src := filepath.Join(t.TempDir(), "lib.js")
+	if err := os.WriteFile(src, []byte(content), 0o600); err != nil {
+		t.Fatalf("could not write library: %v", err)
+	}
+
+	sum := sha256.Sum256([]byte(content))
+
+	return "file://" + src, "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func TestFetchChecksum(t *testing.T) {
+	ctx := context.Background()
+	url, sum := writeLibrary(t, "$(function f() { return 1; })")
+
+	t.Run("Matching checksum", func(t *testing.T) {
+		if _, err := Fetch(ctx, &FetchInput{URL: url, Checksum: sum, Path: t.TempDir()}); err != nil {
+			t.Errorf("fetch was not expected to fail: %v", err)
+		}
+	})
+
+	t.Run("Checksum without type", func(t *testing.T) {
+		if _, err := Fetch(ctx, &FetchInput{URL: url, Checksum: sum[len("sha256:"):], Path: t.TempDir()}); err != nil {
+			t.Errorf("fetch was not expected to fail: %v", err)
+		}
+	})
+
+	t.Run("Mismatching checksum", func(t *testing.T) {
+		_, other := writeLibrary(t, "$(function f() { return 2; })")
+
+		cache := t.TempDir()
+		_, err := Fetch(ctx, &FetchInput{URL: url, Checksum: other, Path: cache})
+		if !errors.Is(err, ErrChecksumMismatch) {
+			t.Fatalf("fetch was expected to fail with a checksum mismatch, got: %v", err)
+		}
+
+		if entries, _ := os.ReadDir(cache); len(entries) != 0 {
+			t.Errorf("the unverified file was expected to be removed from the cache")
+		}
+	})
+
+	t.Run("Invalid checksum", func(t *testing.T) {
+		weak := []string{"md5:" + strings.Repeat("0", 32), "sha1:" + strings.Repeat("0", 40), strings.Repeat("0", 32)}
+
+		for _, c := range append([]string{"sha256:abc", "crc32:00000000", "sha1:" + sum[len("sha256:"):], "sha256:zz"}, weak...) {
+			if _, err := Fetch(ctx, &FetchInput{URL: url, Checksum: c, Path: t.TempDir()}); !errors.Is(err, ErrInvalidChecksum) {
+				t.Errorf("fetch was expected to fail with an invalid checksum for '%s', got: %v", c, err)
+			}
+		}
+	})
+
+	t.Run("Poisoned cache", func(t *testing.T) {
+		cache := t.TempDir()
+
+		dst, err := Fetch(ctx, &FetchInput{URL: url, Checksum: sum, Path: cache})
+		if err != nil {
+			t.Fatalf("fetch was not expected to fail: %v", err)
+		}
+
+		if err := os.Remove(dst); err != nil {
+			t.Fatalf("could not remove cached file: %v", err)
+		}
+		if err := os.WriteFile(dst, []byte("$(function f() { return 'evil'; })"), 0o600); err != nil {
+			t.Fatalf("could not poison the cache: %v", err)
+		}
+
+		dst, err = Fetch(ctx, &FetchInput{URL: url, Checksum: sum, Path: cache})
+		if err != nil {
+			t.Fatalf("fetch was not expected to fail: %v", err)
+		}
+
+		content, err := os.ReadFile(dst)
+		if err != nil {
+			t.Fatalf("could not read cached file: %v", err)
+		}
+
+		if string(content) != "$(function f() { return 1; })" {
+			t.Errorf("the poisoned file was expected to be replaced, got: %s", content)
+		}
+	})
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	content := []byte("$(function f() { return 1; })")
+	_, sum := writeLibrary(t, string(content))
+
+	if err := VerifyChecksum(content, sum); err != nil {
+		t.Errorf("verification was not expected to fail: %v", err)
+	}
+
+	if err := VerifyChecksum([]byte("$(function f() { return 2; })"), sum); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("verification was expected to fail with a checksum mismatch, got: %v", err)
+	}
+
+	if err := VerifyChecksum(content, "sha256:abc"); !errors.Is(err, ErrInvalidChecksum) {
+		t.Errorf("verification was expected to fail with an invalid checksum, got: %v", err)
+	}
+}
+
+// writeTree writes the given files in a temporary directory and returns it.
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("could not create directory: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatalf("could not write file: %v", err)
+		}
+	}
+
+	return dir
+}
+
+func TestSplitSource(t *testing.T) {
+	for src, expected := range map[string][2]string{
+		"https://example.com/lib.js":                                  {"https://example.com/lib.js", ""},
+		"git::https://github.com/org/repo.git//functions?ref=v2":      {"git::https://github.com/org/repo.git?ref=v2", "functions"},
+		"git::https://github.com/org/repo.git//functions/*.js?ref=v2": {"git::https://github.com/org/repo.git?ref=v2", "functions/*.js"},
+		"file://./lib/*.js":                                           {"./lib", "*.js"},
+		"/abs/lib/[ab].js":                                            {"/abs/lib", "[ab].js"},
+		"*.js":                                                        {".", "*.js"},
+	} {
+		t.Run(src, func(t *testing.T) {
+			if base, pattern := splitSource(src); base != expected[0] || pattern != expected[1] {
+				t.Errorf("expected (%s, %s), got (%s, %s)", expected[0], expected[1], base, pattern)
+			}
+		})
+	}
+}
+
+func TestFetchAll(t *testing.T) {
+	ctx := context.Background()
+
+	dir := writeTree(t, map[string]string{
+		"a.js":         "$(function a() { return 1; })",
+		"b.js":         "$(function b() { return 1; })",
+		"README.md":    "# Functions",
+		".hidden.js":   "$(function hidden() { return 1; })",
+		".git/config":  "[core]",
+		"sub/c.js":     "$(function c() { return 1; })",
+		"sub/d.txt":    "d",
+		"other/e.js":   "$(function e() { return 1; })",
+		"other/f/g.js": "$(function g() { return 1; })",
+	})
+
+	names := func(files []File) []string {
+		n := make([]string, 0, len(files))
+		for _, f := range files {
+			n = append(n, f.Name)
+		}
+		return n
+	}
+
+	for name, c := range map[string]struct {
+		src      string
+		expected []string
+	}{
+		"Directory":           {dir, []string{"README.md", "a.js", "b.js", "other/e.js", "other/f/g.js", "sub/c.js", "sub/d.txt"}},
+		"Glob":                {dir + "/*.js", []string{"a.js", "b.js"}},
+		"Glob of files":       {"file://" + dir + "/*/*.js", []string{"other/e.js", "sub/c.js"}},
+		"Subdirectory":        {dir + "//other", []string{"e.js", "f/g.js"}},
+		"Subdirectory glob":   {dir + "//sub/*.js", []string{"c.js"}},
+		"File in a directory": {dir + "//sub/c.js", []string{"c.js"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			files, err := FetchAll(ctx, &FetchInput{URL: c.src, Path: t.TempDir()})
+			if err != nil {
+				t.Fatalf("fetch was not expected to fail: %v", err)
+			}
+
+			if actual := names(files); strings.Join(actual, ",") != strings.Join(c.expected, ",") {
+				t.Errorf("expected %v, got %v", c.expected, actual)
+			}
+
+			for _, f := range files {
+				if _, err := os.Stat(f.Path); err != nil {
+					t.Errorf("the file %s was expected to be cached: %v", f.Name, err)
+				}
+			}
+		})
+	}
+
+	t.Run("Archive", func(t *testing.T) {
+		archive := filepath.Join(t.TempDir(), "lib.tar.gz")
+		writeArchive(t, archive, map[string]string{
+			"lib/a.js": "$(function a() { return 1; })",
+			"lib/b.js": "$(function b() { return 1; })",
+		})
+
+		files, err := FetchAll(ctx, &FetchInput{URL: archive + "//lib", Path: t.TempDir()})
+		if err != nil {
+			t.Fatalf("fetch was not expected to fail: %v", err)
+		}
+
+		if actual := names(files); strings.Join(actual, ",") != "a.js,b.js" {
+			t.Errorf("expected the files of the archive, got %v", actual)
+		}
+	})
+
+	t.Run("No match", func(t *testing.T) {
+		if _, err := FetchAll(ctx, &FetchInput{URL: dir + "/*.py", Path: t.TempDir()}); !errors.Is(err, ErrNoMatch) {
+			t.Errorf("fetch was expected to fail without match, got: %v", err)
+		}
+	})
+
+	t.Run("Checksum of several files", func(t *testing.T) {
+		_, sum := writeLibrary(t, "$(function a() { return 1; })")
+
+		if _, err := FetchAll(ctx, &FetchInput{URL: dir + "/*.js", Checksum: sum, Path: t.TempDir()}); !errors.Is(err, ErrChecksumMismatch) {
+			t.Errorf("fetch was expected to fail with a checksum mismatch, got: %v", err)
+		}
+	})
+
+	t.Run("Single file", func(t *testing.T) {
+		if _, err := Fetch(ctx, &FetchInput{URL: dir + "/*.js", Path: t.TempDir()}); err == nil {
+			t.Errorf("fetching several files as one was expected to fail")
+		}
+	})
+
+	t.Run("Cached", func(t *testing.T) {
+		cache := t.TempDir()
+		src := writeTree(t, map[string]string{"a.js": "$(function a() { return 1; })"})
+
+		first, err := FetchAll(ctx, &FetchInput{URL: src, Path: cache})
+		if err != nil {
+			t.Fatalf("fetch was not expected to fail: %v", err)
+		}
+
+		// A file added to the source is not seen until the cache expires
+		if err := os.WriteFile(filepath.Join(src, "b.js"), []byte("$(function b() { return 1; })"), 0o600); err != nil {
+			t.Fatalf("could not write file: %v", err)
+		}
+
+		second, err := FetchAll(ctx, &FetchInput{URL: src, Path: cache, TTL: time.Hour})
+		if err != nil {
+			t.Fatalf("fetch was not expected to fail: %v", err)
+		}
+
+		if strings.Join(names(second), ",") != "a.js" || first[0].Path != second[0].Path {
+			t.Errorf("the cached files were expected to be used, got %v", second)
+		}
+
+		third, err := FetchAll(ctx, &FetchInput{URL: src, Path: cache, Refresh: true})
+		if err != nil {
+			t.Fatalf("fetch was not expected to fail: %v", err)
+		}
+
+		if strings.Join(names(third), ",") != "a.js,b.js" {
+			t.Errorf("the files were expected to be downloaded again, got %v", third)
+		}
+	})
+}
+
+// writeArchive writes a tar.gz archive of the given files.
+func writeArchive(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("could not create archive: %v", err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("could not write archive: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("could not write archive: %v", err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatalf("could not write archive: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("could not write archive: %v", err)
+	}
+}
@@ -0,0 +1,253 @@
+package getter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-getter"
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+// lockVersion is the version of the lock file format.
+const lockVersion = 1
+
+var (
+	ErrLockMismatch = errors.New("library does not match the lock file")
+)
+
+// Lock records the libraries that were fetched, so that later runs
+// fetch exactly the same libraries (like .terraform.lock.hcl does for
+// providers).
+//
+// Libraries can be fetched concurrently through the same lock.
+type Lock struct {
+	// Upgrade allows the libraries that do not match the lock file to
+	// be fetched, in which case the lock file is updated.
+	Upgrade bool
+
+	path    string
+	mu      sync.Mutex
+	file    lockFile
+	changed bool
+}
+
+// lockFile is the JSON representation of a lock file.
+type lockFile struct {
+	Version   int                       `json:"version"`
+	Libraries map[string]*LockedLibrary `json:"libraries"`
+}
+
+// LockedLibrary holds what is recorded about a library in the lock file.
+type LockedLibrary struct {
+	// Normalized is the source of the library, as normalized by go-getter
+	// (e.g. "github.com/org/repo" becomes "git::https://github.com/org/repo.git").
+	Normalized string `json:"normalized"`
+
+	// Revision is the revision the ref of the source resolved to (e.g. the
+	// commit "?ref=main" pointed at), for sources that are repositories.
+	Revision string `json:"revision,omitempty"`
+
+	// Hash is the checksum of the library content (e.g. "sha256:abcd..."),
+	// or the hash of all of its files (e.g. "h1:abcd..."). It is what pins
+	// the library.
+	Hash string `json:"hash"`
+}
+
+// LoadLock reads the lock file found at the given path.
+//
+// A missing lock file is an empty one. If the path is empty, the
+// libraries are not locked at all.
+func LoadLock(path string) (*Lock, error) {
+	l := &Lock{
+		path: path,
+		file: lockFile{Version: lockVersion, Libraries: make(map[string]*LockedLibrary)},
+	}
+
+	if path == "" {
+		return l, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read lock file: %w", err)
+	}
+
+	if err := json.Unmarshal(content, &l.file); err != nil {
+		return nil, fmt.Errorf("could not parse lock file %s: %w", path, err)
+	}
+
+	if l.file.Version != lockVersion {
+		return nil, fmt.Errorf("lock file %s has an unsupported version %d", path, l.file.Version)
+	}
+
+	if l.file.Libraries == nil {
+		l.file.Libraries = make(map[string]*LockedLibrary)
+	}
+
+	return l, nil
+}
+
+// Library returns what is recorded about the library with the given
+// source, or nil if the library is not locked.
+func (l *Lock) Library(source string) *LockedLibrary {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Libraries[source]
+}
+
+// FetchAll downloads a library like FetchAll does, and verifies it against
+// the lock file. Libraries that are not locked yet are recorded.
+//
+// Unless the lock is in upgrade mode, a library that does not match the
+// lock file is not fetched (and a cached copy of a single file is
+// downloaded again). In upgrade mode, the cache is bypassed so that the
+// latest version of each library is recorded.
+func (l *Lock) FetchAll(ctx context.Context, in *FetchInput) ([]File, error) {
+	if l.path == "" {
+		return FetchAll(ctx, in)
+	}
+
+	locked := l.Library(in.URL)
+
+	// The hash of a single file is a checksum, which also verifies the cache
+	verifyLocked := locked != nil && !l.Upgrade && in.Checksum == "" && strings.HasPrefix(locked.Hash, "sha256:")
+
+	fetchIn := *in
+	if verifyLocked {
+		fetchIn.Checksum = locked.Hash
+	}
+	if l.Upgrade {
+		fetchIn.Refresh = true
+	}
+
+	files, revision, err := fetchAll(ctx, &fetchIn)
+	if verifyLocked && errors.Is(err, ErrChecksumMismatch) {
+		return nil, fmt.Errorf("%w (run with FUNC_LOCK_UPGRADE=true to update it): %w", ErrLockMismatch, err)
+	} else if err != nil {
+		return nil, err
+	}
+
+	hash, err := filesHash(files)
+	if err != nil {
+		return nil, fmt.Errorf("could not hash library: %w", err)
+	}
+
+	if locked != nil && locked.Hash != hash && !l.Upgrade {
+		return nil, fmt.Errorf(
+			"%w (run with FUNC_LOCK_UPGRADE=true to update it): expected %s, got %s",
+			ErrLockMismatch, locked.Hash, hash,
+		)
+	}
+
+	normalized := in.URL
+	if pwd, err := os.Getwd(); err == nil {
+		if detected, err := getter.Detect(in.URL, pwd, getter.Detectors); err == nil {
+			normalized = detected
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// The same content can be found at another revision (e.g. when a branch
+	// moved), which is recorded too
+	if locked == nil || locked.Hash != hash || locked.Normalized != normalized || locked.Revision != revision {
+		l.file.Libraries[in.URL] = &LockedLibrary{Normalized: normalized, Revision: revision, Hash: hash}
+		l.changed = true
+	}
+
+	return files, nil
+}
+
+// Save writes the lock file, if anything was recorded since it was loaded.
+//
+// The lock file is replaced atomically, so it is never left half-written.
+func (l *Lock) Save() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.path == "" || !l.changed {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(l.file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not serialize lock file: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("could not write lock file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("could not write lock file: %w", err)
+	}
+
+	if _, err := tmp.Write(append(content, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("could not write lock file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("could not write lock file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		return fmt.Errorf("could not write lock file: %w", err)
+	}
+
+	l.changed = false
+
+	return nil
+}
+
+// filesHash computes the hash of the files of a library: the sha256
+// checksum of the file if there is a single one, or the hash of all of
+// them (like Go modules' `h1:` hashes) otherwise.
+func filesHash(files []File) (string, error) {
+	if len(files) == 1 {
+		return fileHash(files[0].Path)
+	}
+
+	names := make([]string, 0, len(files))
+	paths := make(map[string]string, len(files))
+	for _, f := range files {
+		names = append(names, f.Name)
+		paths[f.Name] = f.Path
+	}
+
+	return dirhash.Hash1(names, func(name string) (io.ReadCloser, error) {
+		return os.Open(paths[name])
+	})
+}
+
+// fileHash computes the sha256 checksum of a file, in the `type:value` format.
+func fileHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,208 @@
+package getter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLock(t *testing.T) {
+	ctx := context.Background()
+
+	src := filepath.Join(t.TempDir(), "lib.js")
+	write := func(content string) {
+		if err := os.WriteFile(src, []byte(content), 0o600); err != nil {
+			t.Fatalf("could not write library: %v", err)
+		}
+	}
+	write("$(function f() { return 1; })")
+
+	url := "file://" + src
+	lockPath := filepath.Join(t.TempDir(), ".func.lock.json")
+
+	// The first run records the library
+	lock, err := LoadLock(lockPath)
+	if err != nil {
+		t.Fatalf("lock was not expected to fail: %v", err)
+	}
+
+	if _, err := lock.FetchAll(ctx, &FetchInput{URL: url, Path: t.TempDir()}); err != nil {
+		t.Fatalf("fetch was not expected to fail: %v", err)
+	}
+
+	if err := lock.Save(); err != nil {
+		t.Fatalf("lock file could not be saved: %v", err)
+	}
+
+	lock, err = LoadLock(lockPath)
+	if err != nil {
+		t.Fatalf("lock was not expected to fail: %v", err)
+	}
+
+	locked := lock.Library(url)
+	if locked == nil || !strings.HasPrefix(locked.Hash, "sha256:") || locked.Normalized == "" {
+		t.Fatalf("library was expected to be recorded, got %+v", locked)
+	}
+
+	// A library that changed is rejected
+	write("$(function f() { return 2; })")
+
+	if _, err := lock.FetchAll(ctx, &FetchInput{URL: url, Path: t.TempDir()}); !errors.Is(err, ErrLockMismatch) {
+		t.Fatalf("fetch was expected to fail with a lock mismatch, got: %v", err)
+	}
+
+	// Unless the lock is upgraded
+	lock.Upgrade = true
+
+	if _, err := lock.FetchAll(ctx, &FetchInput{URL: url, Path: t.TempDir()}); err != nil {
+		t.Fatalf("fetch was not expected to fail: %v", err)
+	}
+
+	if err := lock.Save(); err != nil {
+		t.Fatalf("lock file could not be saved: %v", err)
+	}
+
+	lock, err = LoadLock(lockPath)
+	if err != nil {
+		t.Fatalf("lock was not expected to fail: %v", err)
+	}
+
+	if upgraded := lock.Library(url); upgraded == nil || upgraded.Hash == locked.Hash {
+		t.Errorf("library was expected to be upgraded, got %+v", upgraded)
+	}
+
+	if _, err := lock.FetchAll(ctx, &FetchInput{URL: url, Path: t.TempDir()}); err != nil {
+		t.Errorf("fetch was not expected to fail after the upgrade: %v", err)
+	}
+}
+
+func TestLoadLock(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := map[string]struct {
+		content string
+		err     bool
+	}{
+		"Valid":               {`{"version": 1, "libraries": {"a": {"normalized": "a", "hash": "sha256:00"}}}`, false},
+		"Without libraries":   {`{"version": 1}`, false},
+		"Unsupported version": {`{"version": 2, "libraries": {}}`, true},
+		"Malformed":           {`{"version": 1,`, true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name+".json")
+			if err := os.WriteFile(path, []byte(test.content), 0o600); err != nil {
+				t.Fatalf("could not write lock file: %v", err)
+			}
+
+			_, err := LoadLock(path)
+			if test.err && err == nil {
+				t.Errorf("lock was expected to fail")
+			}
+
+			if !test.err && err != nil {
+				t.Errorf("lock was not expected to fail: %v", err)
+			}
+		})
+	}
+
+	if _, err := LoadLock(filepath.Join(dir, "missing.json")); err != nil {
+		t.Errorf("a missing lock file was not expected to fail: %v", err)
+	}
+}
+
+func TestLockDirectory(t *testing.T) {
+	ctx := context.Background()
+
+	src := writeTree(t, map[string]string{
+		"a.js": "$(function a() { return 1; })",
+		"b.js": "$(function b() { return 1; })",
+	})
+
+	lock, err := LoadLock(filepath.Join(t.TempDir(), ".func.lock.json"))
+	if err != nil {
+		t.Fatalf("lock was not expected to fail: %v", err)
+	}
+
+	if _, err := lock.FetchAll(ctx, &FetchInput{URL: src, Path: t.TempDir()}); err != nil {
+		t.Fatalf("fetch was not expected to fail: %v", err)
+	}
+
+	if locked := lock.Library(src); locked == nil || !strings.HasPrefix(locked.Hash, "h1:") {
+		t.Fatalf("the files of the library were expected to be recorded, got %+v", locked)
+	}
+
+	// A file added to the library is rejected
+	if err := os.WriteFile(filepath.Join(src, "c.js"), []byte("$(function c() { return 1; })"), 0o600); err != nil {
+		t.Fatalf("could not write file: %v", err)
+	}
+
+	if _, err := lock.FetchAll(ctx, &FetchInput{URL: src, Path: t.TempDir()}); !errors.Is(err, ErrLockMismatch) {
+		t.Errorf("fetch was expected to fail with a lock mismatch, got: %v", err)
+	}
+}
+
+func TestLockRevision(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	ctx := context.Background()
+
+	repo := writeTree(t, map[string]string{
+		"lib.js": "$(function f() { return 1; })",
+	})
+
+	gitCmd := func(args ...string) string {
+		t.Helper()
+
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repo
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("git %v failed: %v", args, err)
+		}
+
+		return strings.TrimSpace(string(out))
+	}
+
+	gitCmd("init", "--quiet")
+	gitCmd("add", ".")
+	gitCmd("commit", "--quiet", "-m", "init")
+	head := gitCmd("rev-parse", "HEAD")
+
+	url := "git::file://" + repo
+	cacheDir := t.TempDir()
+
+	lock, err := LoadLock(filepath.Join(t.TempDir(), ".func.lock.json"))
+	if err != nil {
+		t.Fatalf("lock was not expected to fail: %v", err)
+	}
+
+	if _, err := lock.FetchAll(ctx, &FetchInput{URL: url, Path: cacheDir}); err != nil {
+		t.Fatalf("fetch was not expected to fail: %v", err)
+	}
+
+	if locked := lock.Library(url); locked == nil || locked.Revision != head {
+		t.Fatalf("the commit %s was expected to be recorded, got %+v", head, locked)
+	}
+
+	// The revision of a cached library is still known
+	lock, err = LoadLock(filepath.Join(t.TempDir(), ".func.lock.json"))
+	if err != nil {
+		t.Fatalf("lock was not expected to fail: %v", err)
+	}
+
+	if _, err := lock.FetchAll(ctx, &FetchInput{URL: url, Path: cacheDir}); err != nil {
+		t.Fatalf("fetch was not expected to fail: %v", err)
+	}
+
+	if locked := lock.Library(url); locked == nil || locked.Revision != head {
+		t.Errorf("the commit %s was expected to be recorded from the cache, got %+v", head, locked)
+	}
+}
@@ -0,0 +1,82 @@
+package getter
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Mirror maps the sources starting with a prefix to a local directory
+// (like Terraform's filesystem mirrors do for providers), so that they are
+// read from the disk instead of being downloaded.
+//
+// The rest of the source, without its query, is the path of the file in
+// the directory. For example, with the prefix "git::https://github.com/org/libs.git"
+// and the path "vendor/libs", the source "git::https://github.com/org/libs.git//lib.js?ref=v2"
+// is read from "vendor/libs/lib.js".
+type Mirror struct {
+	// Prefix is the beginning of the sources that are mirrored.
+	Prefix string
+
+	// Path is the local directory holding the mirrored files. If a source
+	// is exactly the prefix, it can also be the mirrored file itself (or
+	// the mirrored directory, when the source is a directory).
+	Path string
+}
+
+// resolve returns the path of the mirrored file of the given source, if
+// the source matches the prefix.
+func (m *Mirror) resolve(src string) (string, bool) {
+	if m.Prefix == "" || !strings.HasPrefix(src, m.Prefix) {
+		return "", false
+	}
+
+	rest := strings.TrimPrefix(src, m.Prefix)
+
+	// The prefix must end on a path boundary, so that "lib" does not match "library.js"
+	if rest != "" && !strings.HasSuffix(m.Prefix, "/") && !strings.HasPrefix(rest, "/") && !strings.HasPrefix(rest, "?") {
+		return "", false
+	}
+
+	rest, _, _ = strings.Cut(rest, "?")
+	rest = strings.TrimLeft(rest, "/")
+
+	p := filepath.Join(m.Path, filepath.FromSlash(rest))
+
+	// The source must not escape the mirror directory
+	if rel, err := filepath.Rel(m.Path, p); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	return p, true
+}
+
+// findMirrored returns the mirrored files of the given source.
+//
+// When several mirrors match the source, the one with the longest prefix
+// is used. A source that is not found in the mirror is not mirrored.
+func findMirrored(mirrors []Mirror, src string) ([]File, bool) {
+	found, longest := "", -1
+	for i := range mirrors {
+		if p, ok := mirrors[i].resolve(src); ok && len(mirrors[i].Prefix) > longest {
+			found, longest = p, len(mirrors[i].Prefix)
+		}
+	}
+
+	if found == "" {
+		return nil, false
+	}
+
+	// The source can select files with a glob, which is matched in the mirror
+	root, pattern := found, ""
+	if i := strings.IndexAny(found, "*["); i >= 0 {
+		root = filepath.Dir(found[:i+1])
+		pattern, _ = filepath.Rel(root, found)
+	}
+
+	files, err := collect(root, pattern)
+	if err != nil {
+		return nil, false
+	}
+
+	return files, true
+}
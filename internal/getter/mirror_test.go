@@ -0,0 +1,107 @@
+package getter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMirrorResolve(t *testing.T) {
+	mirror := &Mirror{Prefix: "git::https://github.com/org/libs.git", Path: "vendor"}
+
+	for name, c := range map[string]struct {
+		src      string
+		expected string
+		ok       bool
+	}{
+		"Subdirectory":   {"git::https://github.com/org/libs.git//functions/lib.js?ref=v2", filepath.Join("vendor", "functions", "lib.js"), true},
+		"Exact":          {"git::https://github.com/org/libs.git", "vendor", true},
+		"Query":          {"git::https://github.com/org/libs.git?ref=v2", "vendor", true},
+		"Other source":   {"git::https://github.com/org/other.git//lib.js", "", false},
+		"Not a boundary": {"git::https://github.com/org/libs.git2//lib.js", "", false},
+		"Escaping":       {"git::https://github.com/org/libs.git//../secret.js", "", false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p, ok := mirror.resolve(c.src)
+			if ok != c.ok || p != c.expected {
+				t.Errorf("expected (%s, %v), got (%s, %v)", c.expected, c.ok, p, ok)
+			}
+		})
+	}
+}
+
+func TestFetchMirror(t *testing.T) {
+	ctx := context.Background()
+
+	s3 := t.TempDir()
+	git := t.TempDir()
+	for dir, content := range map[string]string{
+		s3:  "$(function s3() { return 1; })",
+		git: "$(function git() { return 1; })",
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, "functions"), 0o755); err != nil {
+			t.Fatalf("could not create mirror: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "functions", "lib.js"), []byte(content), 0o600); err != nil {
+			t.Fatalf("could not write mirrored library: %v", err)
+		}
+	}
+
+	mirrors := []Mirror{
+		{Prefix: "s3::https://s3.amazonaws.com/bucket/", Path: s3},
+		{Prefix: "git::https://github.com/org/libs.git", Path: t.TempDir()},
+		{Prefix: "git::https://github.com/org/libs.git//functions", Path: filepath.Join(git, "functions")},
+	}
+
+	t.Run("Mirrored", func(t *testing.T) {
+		dst, err := Fetch(ctx, &FetchInput{URL: "s3::https://s3.amazonaws.com/bucket/functions/lib.js", Path: t.TempDir(), Mirrors: mirrors, Offline: true})
+		if err != nil {
+			t.Fatalf("fetch was not expected to fail: %v", err)
+		}
+
+		if dst != filepath.Join(s3, "functions", "lib.js") {
+			t.Errorf("the mirrored file was expected to be used, got: %s", dst)
+		}
+	})
+
+	t.Run("Longest prefix", func(t *testing.T) {
+		dst, err := Fetch(ctx, &FetchInput{URL: "git::https://github.com/org/libs.git//functions/lib.js?ref=v2", Path: t.TempDir(), Mirrors: mirrors, Offline: true})
+		if err != nil {
+			t.Fatalf("fetch was not expected to fail: %v", err)
+		}
+
+		if dst != filepath.Join(git, "functions", "lib.js") {
+			t.Errorf("the most specific mirror was expected to be used, got: %s", dst)
+		}
+	})
+
+	t.Run("Checksum", func(t *testing.T) {
+		_, other := writeLibrary(t, "$(function f() { return 2; })")
+
+		_, err := Fetch(ctx, &FetchInput{URL: "s3::https://s3.amazonaws.com/bucket/functions/lib.js", Checksum: other, Path: t.TempDir(), Mirrors: mirrors})
+		if !errors.Is(err, ErrChecksumMismatch) {
+			t.Errorf("fetch was expected to fail with a checksum mismatch, got: %v", err)
+		}
+	})
+
+	t.Run("Mirrored glob", func(t *testing.T) {
+		files, err := FetchAll(ctx, &FetchInput{URL: "git::https://github.com/org/libs.git//functions/*.js?ref=v2", Path: t.TempDir(), Mirrors: mirrors, Offline: true})
+		if err != nil {
+			t.Fatalf("fetch was not expected to fail: %v", err)
+		}
+
+		// The files are named like the downloaded ones would be
+		if len(files) != 1 || files[0].Name != "lib.js" || files[0].Path != filepath.Join(git, "functions", "lib.js") {
+			t.Errorf("the mirrored files were expected to be used, got: %v", files)
+		}
+	})
+
+	t.Run("Missing from the mirror", func(t *testing.T) {
+		_, err := Fetch(ctx, &FetchInput{URL: "s3::https://s3.amazonaws.com/bucket/missing.js", Path: t.TempDir(), Mirrors: mirrors, Offline: true})
+		if !errors.Is(err, ErrNotCached) {
+			t.Errorf("fetch was expected to fall back on the cache, got: %v", err)
+		}
+	})
+}
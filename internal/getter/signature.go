@@ -0,0 +1,89 @@
+package getter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+var (
+	ErrInvalidKey       = errors.New("invalid public key")
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// Keyring holds the OpenPGP public keys that are trusted to sign libraries.
+type Keyring struct {
+	entities openpgp.EntityList
+}
+
+// ParseKeyring parses ASCII-armored OpenPGP public keys. Each of the given
+// strings can hold any number of armored keys.
+func ParseKeyring(keys ...string) (*Keyring, error) {
+	k := &Keyring{}
+
+	for i, key := range keys {
+		// An armored block holds a single key ring, so concatenated
+		// blocks are read one by one
+		blocks := strings.SplitAfter(key, "-----END PGP PUBLIC KEY BLOCK-----")
+		for _, block := range blocks {
+			if strings.TrimSpace(block) == "" {
+				continue
+			}
+
+			entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(block))
+			if err != nil {
+				return nil, fmt.Errorf("%w: key %d: %w", ErrInvalidKey, i, err)
+			}
+
+			k.entities = append(k.entities, entities...)
+		}
+	}
+
+	if len(k.entities) == 0 {
+		return nil, fmt.Errorf("%w: no public key found", ErrInvalidKey)
+	}
+
+	return k, nil
+}
+
+// Verify checks that the signature at sigPath is a valid detached signature
+// (armored or binary) of the file at path, made by one of the trusted keys.
+//
+// It returns the identity of the signer.
+func (k *Keyring) Verify(path string, sigPath string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return k.VerifyContent(content, sigPath)
+}
+
+// VerifyContent checks that the signature at sigPath is a valid detached
+// signature of the given content, like Verify does for a file.
+func (k *Keyring) VerifyContent(content []byte, sigPath string) (string, error) {
+	signature, err := os.ReadFile(sigPath)
+	if err != nil {
+		return "", err
+	}
+
+	var signer *openpgp.Entity
+	if bytes.HasPrefix(bytes.TrimSpace(signature), []byte("-----BEGIN")) {
+		signer, err = openpgp.CheckArmoredDetachedSignature(k.entities, bytes.NewReader(content), bytes.NewReader(signature), nil)
+	} else {
+		signer, err = openpgp.CheckDetachedSignature(k.entities, bytes.NewReader(content), bytes.NewReader(signature), nil)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	if identity := signer.PrimaryIdentity(); identity != nil {
+		return identity.Name, nil
+	}
+
+	return fmt.Sprintf("%X", signer.PrimaryKey.Fingerprint), nil
+}
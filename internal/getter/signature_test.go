@@ -0,0 +1,150 @@
+package getter
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// newSigner creates an OpenPGP key and returns it along with its armored public key.
+func newSigner(t *testing.T, name string) (*openpgp.Entity, string) {
+	t.Helper()
+
+	entity, err := openpgp.NewEntity(name, "", name+"@example.com", &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})
+	if err != nil {
+		t.Fatalf("could not create key: %v", err)
+	}
+
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatalf("could not armor key: %v", err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatalf("could not serialize key: %v", err)
+	}
+	w.Close()
+
+	return entity, buf.String()
+}
+
+// sign writes a detached signature of the given file next to it.
+func sign(t *testing.T, signer *openpgp.Entity, path string, armored bool) string {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read file: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if armored {
+		err = openpgp.ArmoredDetachSign(&buf, signer, bytes.NewReader(content), nil)
+	} else {
+		err = openpgp.DetachSign(&buf, signer, bytes.NewReader(content), nil)
+	}
+	if err != nil {
+		t.Fatalf("could not sign file: %v", err)
+	}
+
+	sigPath := path + ".sig"
+	if err := os.WriteFile(sigPath, buf.Bytes(), 0o600); err != nil {
+		t.Fatalf("could not write signature: %v", err)
+	}
+
+	return sigPath
+}
+
+func TestKeyringVerify(t *testing.T) {
+	platform, platformKey := newSigner(t, "Platform")
+	other, otherKey := newSigner(t, "Other")
+
+	lib := filepath.Join(t.TempDir(), "lib.js")
+	if err := os.WriteFile(lib, []byte("$(function f() { return 1; })"), 0o600); err != nil {
+		t.Fatalf("could not write library: %v", err)
+	}
+
+	keyring, err := ParseKeyring(platformKey)
+	if err != nil {
+		t.Fatalf("keyring was not expected to fail: %v", err)
+	}
+
+	t.Run("Armored signature", func(t *testing.T) {
+		signer, err := keyring.Verify(lib, sign(t, platform, lib, true))
+		if err != nil {
+			t.Fatalf("verification was not expected to fail: %v", err)
+		}
+
+		if !strings.Contains(signer, "Platform") {
+			t.Errorf("wrong signer: %s", signer)
+		}
+	})
+
+	t.Run("Binary signature", func(t *testing.T) {
+		if _, err := keyring.Verify(lib, sign(t, platform, lib, false)); err != nil {
+			t.Errorf("verification was not expected to fail: %v", err)
+		}
+	})
+
+	t.Run("Untrusted signer", func(t *testing.T) {
+		if _, err := keyring.Verify(lib, sign(t, other, lib, true)); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("verification was expected to fail, got: %v", err)
+		}
+	})
+
+	t.Run("Tampered library", func(t *testing.T) {
+		sigPath := sign(t, platform, lib, true)
+
+		tampered := filepath.Join(t.TempDir(), "lib.js")
+		if err := os.WriteFile(tampered, []byte("$(function f() { return 2; })"), 0o600); err != nil {
+			t.Fatalf("could not write library: %v", err)
+		}
+
+		if _, err := keyring.Verify(tampered, sigPath); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("verification was expected to fail, got: %v", err)
+		}
+	})
+
+	t.Run("Content", func(t *testing.T) {
+		sigPath := sign(t, platform, lib, true)
+
+		if _, err := keyring.VerifyContent([]byte("$(function f() { return 1; })"), sigPath); err != nil {
+			t.Errorf("verification was not expected to fail: %v", err)
+		}
+
+		if _, err := keyring.VerifyContent([]byte("$(function f() { return 2; })"), sigPath); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("verification was expected to fail, got: %v", err)
+		}
+	})
+
+	t.Run("Multiple keys", func(t *testing.T) {
+		both, err := ParseKeyring(platformKey + "\n" + otherKey)
+		if err != nil {
+			t.Fatalf("keyring was not expected to fail: %v", err)
+		}
+
+		if _, err := both.Verify(lib, sign(t, other, lib, true)); err != nil {
+			t.Errorf("verification was not expected to fail: %v", err)
+		}
+	})
+}
+
+func TestParseKeyring(t *testing.T) {
+	for name, key := range map[string]string{
+		"Empty":   "",
+		"Garbage": "not a key",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseKeyring(key); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("keyring was expected to fail, got: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package javascript
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/parser"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+var (
+	astNodeType    = reflect.TypeOf((*ast.Node)(nil)).Elem()
+	astPackagePath = astNodeType.PkgPath()
+)
+
+// javaScriptFunctionSource holds what is statically known about a function
+// literal found in a library: its arguments and its documentation.
+type javaScriptFunctionSource struct {
+	argNames []string
+	metadata *JavaScriptFunctionMetadata
+}
+
+// parseScriptFunctions parses a JavaScript script file into an AST and
+// returns every function literal found in it, indexed by its source code.
+//
+// Each JSDoc comment is attached to the function it documents, which is
+// the function the comment directly precedes. The comment can precede the
+// function itself, or the statement that declares or registers it:
+//
+//	/** ... */ function f(a) {}
+//	/** ... */ const f = (a) => {}
+//	/** ... */ $(function f(a) {})
+//	$({ /** ... */ f: function (a) {} })
+//
+// The path is only used to give context to the diagnostics.
+func parseScriptFunctions(path string, src string) (map[string][]*javaScriptFunctionSource, diag.Diagnostics) {
+	diags := diag.Diagnostics{}
+
+	program, err := parser.ParseFile(nil, path, src, 0)
+	if err != nil {
+		diags.AddError("Cannot parse library.", fmt.Sprintf("%s: %v.", path, err))
+		return nil, diags
+	}
+
+	res := make(map[string][]*javaScriptFunctionSource)
+
+	walkAST(reflect.ValueOf(program), nil, func(node ast.Node, parents []ast.Node) {
+		var (
+			source string
+			params *ast.ParameterList
+		)
+
+		switch fn := node.(type) {
+		case *ast.FunctionLiteral:
+			source, params = fn.Source, fn.ParameterList
+		case *ast.ArrowFunctionLiteral:
+			source, params = fn.Source, fn.ParameterList
+		default:
+			return
+		}
+
+		fnSrc := &javaScriptFunctionSource{
+			argNames: extractArgNames(params),
+		}
+
+		for _, pos := range documentationAnchors(node, parents) {
+			doc, offset, ok := leadingJSDoc(src, pos)
+			if !ok {
+				continue
+			}
+
+			// The JSDoc content starts on the same line as the comment opening
+			line := strings.Count(src[:offset], "\n") + 1
+
+			md, ds := parseJSDoc(path, line, doc)
+			diags.Append(ds...)
+
+			fnSrc.metadata = md
+			break
+		}
+
+		res[source] = append(res[source], fnSrc)
+	})
+
+	return res, diags
+}
+
+// parseFunctionSource parses the source code of a single function.
+//
+// It is used for functions that were not found in the library AST
+// (e.g. functions created dynamically).
+func parseFunctionSource(source string) (*javaScriptFunctionSource, error) {
+	// Functions are parsed as expressions, methods as part of an object
+	for _, wrapped := range []string{"(" + source + ")", "({" + source + "})"} {
+		program, err := parser.ParseFile(nil, "", wrapped, 0)
+		if err != nil {
+			continue
+		}
+
+		var fnSrc *javaScriptFunctionSource
+		walkAST(reflect.ValueOf(program), nil, func(node ast.Node, _ []ast.Node) {
+			if fnSrc != nil {
+				return
+			}
+
+			switch fn := node.(type) {
+			case *ast.FunctionLiteral:
+				fnSrc = &javaScriptFunctionSource{argNames: extractArgNames(fn.ParameterList)}
+			case *ast.ArrowFunctionLiteral:
+				fnSrc = &javaScriptFunctionSource{argNames: extractArgNames(fn.ParameterList)}
+			}
+		})
+
+		if fnSrc != nil {
+			return fnSrc, nil
+		}
+	}
+
+	return nil, fmt.Errorf("function source cannot be parsed")
+}
+
+// extractArgNames reads the argument names from a parameter list.
+//
+// Arguments that are destructured do not have a name, so they are named
+// after their position. Rest parameters are not Terraform parameters,
+// so they are ignored.
+func extractArgNames(params *ast.ParameterList) []string {
+	if params == nil {
+		return []string{}
+	}
+
+	names := make([]string, len(params.List))
+	for i, binding := range params.List {
+		if id, ok := binding.Target.(*ast.Identifier); ok {
+			names[i] = id.Name.String()
+		} else {
+			names[i] = fmt.Sprintf("arg%d", i)
+		}
+	}
+
+	return names
+}
+
+// documentationAnchors returns the positions that a JSDoc comment can precede
+// in order to document a function, from the closest to the farthest one.
+func documentationAnchors(fn ast.Node, parents []ast.Node) []int {
+	anchors := []int{offsetOf(fn)}
+
+	child := fn
+	for i := len(parents) - 1; i >= 0; i-- {
+		parent := parents[i]
+
+		switch p := parent.(type) {
+		case *ast.CallExpression:
+			// $(function f() {}, ...)
+			if len(p.ArgumentList) == 0 || p.ArgumentList[0] != child {
+				return anchors
+			}
+		case *ast.Binding:
+			// const f = function () {}
+			if p.Initializer != child {
+				return anchors
+			}
+		case *ast.LexicalDeclaration:
+			if p.List[0] != child {
+				return anchors
+			}
+		case *ast.VariableStatement:
+			if p.List[0] != child {
+				return anchors
+			}
+		case *ast.AssignExpression:
+			// module.exports.f = function () {}
+			if p.Right != child {
+				return anchors
+			}
+		case *ast.PropertyKeyed:
+			// { f: function () {} }
+			if p.Value != child {
+				return anchors
+			}
+
+			// Properties are the farthest anchor, the object is not documenting them
+			return append(anchors, offsetOf(p))
+		case *ast.FunctionDeclaration, *ast.ExpressionStatement:
+			// Same node, different wrapping
+		default:
+			return anchors
+		}
+
+		anchors = append(anchors, offsetOf(parent))
+		child = parent
+	}
+
+	return anchors
+}
+
+// leadingJSDoc returns the content of the JSDoc comment that directly
+// precedes a position in the source (only whitespace can separate them),
+// along with the offset of the content in the source.
+func leadingJSDoc(src string, pos int) (string, int, bool) {
+	end := len(strings.TrimRight(src[:pos], " \t\r\n"))
+
+	if !strings.HasSuffix(src[:end], "*/") {
+		return "", 0, false
+	}
+
+	// Only the comment right before the position is considered, it must not
+	// be mistaken for an earlier JSDoc comment
+	start := strings.LastIndex(src[:end-2], "/*")
+	if start == -1 || start+3 > end-2 || src[start+2] != '*' {
+		return "", 0, false
+	}
+
+	return src[start+3 : end-2], start + 3, true
+}
+
+// offsetOf returns the offset of a node in the source.
+//
+// The AST indexes are 1-based, since the file is not part of a set.
+func offsetOf(node ast.Node) int {
+	return int(node.Idx0()) - 1
+}
+
+// walkAST visits every node of an AST, depth-first, along with its ancestors.
+//
+// The goja AST does not provide a visitor, so the tree is walked using reflection.
+// Hoisted declarations are ignored, since they are already part of the tree.
+func walkAST(v reflect.Value, parents []ast.Node, visit func(ast.Node, []ast.Node)) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if !v.IsNil() {
+			walkAST(v.Elem(), parents, visit)
+		}
+	case reflect.Pointer:
+		if v.IsNil() || v.Type().Elem().PkgPath() != astPackagePath {
+			return
+		}
+
+		if v.Type().Implements(astNodeType) {
+			node := v.Interface().(ast.Node) //nolint:forcetypeassert
+			visit(node, parents)
+			parents = append(parents[:len(parents):len(parents)], node)
+		}
+
+		walkAST(v.Elem(), parents, visit)
+	case reflect.Struct:
+		if v.Type().PkgPath() != astPackagePath {
+			return
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() || field.Name == "DeclarationList" {
+				continue
+			}
+
+			walkAST(v.Field(i), parents, visit)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			walkAST(v.Index(i), parents, visit)
+		}
+	}
+}
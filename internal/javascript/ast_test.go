@@ -0,0 +1,149 @@
+package javascript
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJSDocAssociation(t *testing.T) {
+	funcs := parseLibrary(t, `
+/**
+ * Multi-line signature.
+ */
+$(function multiline(
+  a,
+  b
+) {
+  return a + b;
+})
+
+/** Minified one. */$(function m1(a){return a});/** Minified two. */$(function m2(b){return b})
+
+/**
+ * Declared first.
+ * @param {string} s - The string.
+ */
+function declared(s) {
+  return s;
+}
+
+/**
+ * Declared as a constant.
+ * @param {number} n - The number.
+ */
+const constant = (n) => n;
+
+$(declared);
+$(constant);
+
+$({
+  /**
+   * Property of an object.
+   */
+  property: function (x) {
+    return x;
+  },
+});
+
+/**
+ * Identical one.
+ */
+$(function (a) { return a; }, { name: "identical_one" })
+
+/**
+ * Identical two.
+ */
+$(function (a) { return a; }, { name: "identical_two" })
+
+/**
+ * Documents the call, not the callback.
+ */
+$(function outer(list) {
+  return list.map((item) => item);
+})
+
+$(function destructured({ a }, b, ...rest) {
+  return a + b;
+})
+
+/** Doc for a. */
+$(function a(x) { return x; })
+/* plain */
+$(function b(y) { return y; })
+`)
+
+	tests := []struct {
+		name    string
+		summary string
+		params  []string
+	}{
+		{"multiline", "Multi-line signature.", []string{"a", "b"}},
+		{"m1", "Minified one.", []string{"a"}},
+		{"m2", "Minified two.", []string{"b"}},
+		{"declared", "Declared first.", []string{"s"}},
+		{"constant", "Declared as a constant.", []string{"n"}},
+		{"property", "Property of an object.", []string{"x"}},
+		{"identical_one", "Identical one.", []string{"a"}},
+		{"identical_two", "Identical two.", []string{"a"}},
+		{"outer", "Documents the call, not the callback.", []string{"list"}},
+		{"destructured", "", []string{"arg0", "b"}},
+		{"a", "Doc for a.", []string{"x"}},
+		{"b", "", []string{"y"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f, ok := funcs[test.name]
+			if !ok {
+				t.Fatalf("function %s was not registered", test.name)
+			}
+
+			if f.Summary() != test.summary {
+				t.Errorf("wrong summary:\nwant: %q\ngot : %q", test.summary, f.Summary())
+			}
+
+			params, _ := f.TerraformParameters()
+			names := make([]string, len(params))
+			for i, p := range params {
+				names[i] = p.GetName()
+			}
+
+			if !reflect.DeepEqual(names, test.params) {
+				t.Errorf("wrong parameters:\nwant: %v\ngot : %v", test.params, names)
+			}
+		})
+	}
+}
+
+func TestParseScriptFunctionsLines(t *testing.T) {
+	src := `// A library
+
+/**
+ * Sums.
+ * @unknown
+ */
+$(function sum(a, b) {
+  return a + b;
+})`
+
+	sources, diags := parseScriptFunctions("lib.js", src)
+
+	fnSrc, ok := sources["function sum(a, b) {\n  return a + b;\n}"]
+	if !ok || len(fnSrc) != 1 || fnSrc[0].metadata == nil {
+		t.Fatalf("metadata was not associated with the function")
+	}
+
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly one warning, got %d", len(diags))
+	}
+
+	if want := "lib.js:5: @unknown is not supported and it will be ignored."; diags[0].Detail() != want {
+		t.Errorf("wrong warning received:\nwant: %s\ngot : %s", want, diags[0].Detail())
+	}
+}
+
+func TestParseScriptFunctionsSyntaxError(t *testing.T) {
+	if _, diags := parseScriptFunctions("lib.js", `$(function broken(a { })`); !diags.HasError() {
+		t.Errorf("parsing was expected to fail")
+	}
+}
@@ -1,16 +1,24 @@
 package javascript
 
 import (
+	"container/list"
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"strings"
+	"sync"
 	"terraform-provider-func/internal/runtime"
 	"terraform-provider-func/tftypes"
 	"terraform-provider-func/tftypes/tfarg"
+	"terraform-provider-func/tftypes/tfconvert"
 	"terraform-provider-func/tftypes/tfgoja"
+	"time"
 
 	"github.com/dop251/goja"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	tffunc "github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
 	"github.com/ssoroka/slice"
 )
 
@@ -24,17 +32,23 @@ type JavaScriptArgument struct {
 	name        string
 	description string
 	param       tffunc.Parameter
+	decoder     argumentDecoder
 }
 
 // JavaScriptFunction is a concrete implementation of the Function interface
 // and represents a Function that can be executed on a JavaScript runtime.
 type JavaScriptFunction struct {
-	name        string
-	callable    runtime.Callable
-	args        []JavaScriptArgument
-	ret         tffunc.Return
-	summary     string
-	description string
+	name                string
+	callable            runtime.Callable
+	args                []JavaScriptArgument
+	ret                 tffunc.Return
+	summary             string
+	description         string
+	markdownDescription string
+	deprecationMessage  string
+	pure                bool
+	conversionOptions   tfconvert.Options
+	memo                *memoCache
 }
 
 func (f *JavaScriptFunction) Name() string {
@@ -50,7 +64,15 @@ func (f *JavaScriptFunction) Description() string {
 }
 
 func (f *JavaScriptFunction) MarkdownDescription() string {
-	return f.description
+	return f.markdownDescription
+}
+
+func (f *JavaScriptFunction) DeprecationMessage() string {
+	return f.deprecationMessage
+}
+
+func (f *JavaScriptFunction) ConversionOptions() tfconvert.Options {
+	return f.conversionOptions
 }
 
 func (f *JavaScriptFunction) AllocateParameters() ([]any, error) {
@@ -74,7 +96,70 @@ func (f *JavaScriptFunction) TerraformReturn() (tffunc.Return, error) {
 }
 
 func (f *JavaScriptFunction) Execute(args ...any) (any, error) {
-	return f.callable(args...)
+	args, err := f.coerceArguments(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if !f.pure {
+		return f.callable(args...)
+	}
+
+	// Pure functions always return the same result for the same arguments,
+	// so the result of a previous call can be reused.
+	key, err := memoizationKey(args)
+	if err != nil {
+		return f.callable(args...)
+	}
+
+	if res, ok := f.memo.get(key); ok {
+		return res, nil
+	}
+
+	res, err := f.callable(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	f.memo.add(key, res)
+
+	return res, nil
+}
+
+// coerceArguments converts the arguments into the types the parameters were
+// declared with, since Terraform can only enforce some of them (e.g. tuple
+// parameters are received as dynamic values).
+func (f *JavaScriptFunction) coerceArguments(args []any) ([]any, error) {
+	ctx := context.Background()
+
+	coerced := make([]any, len(args))
+	for i, arg := range args {
+		val, ok := arg.(attr.Value)
+		if !ok || i >= len(f.args) || val.IsNull() {
+			coerced[i] = arg
+			continue
+		}
+
+		if dv, ok := tftypes.EnsurePointer(val).(*basetypes.DynamicValue); ok && dv.IsUnderlyingValueNull() {
+			coerced[i] = arg
+			continue
+		}
+
+		typ := tfarg.ParameterType(f.args[i].param)
+		if !tftypes.IsTupleType(typ) {
+			coerced[i] = arg
+			continue
+		}
+
+		res, err := tfconvert.Convert(ctx, val, typ)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d (%s) is invalid: %w", i, f.args[i].name, err)
+		}
+
+		coerced[i] = res
+	}
+
+	return coerced, nil
 }
 
 type javaScriptArgumentInput struct {
@@ -90,6 +175,15 @@ type javascriptFunctionInput struct {
 	args        []javaScriptArgumentInput
 	retJsType   string
 	callable    goja.Callable
+	examples    []string
+	throws      []string
+	since       string
+	see         []string
+	deprecated  string
+	pure        bool
+	timeout     time.Duration
+	conversion  tfconvert.Options
+	values      tfgoja.Options
 }
 
 // NewJavaScriptFunction creates a new JavaScriptFunction.
@@ -121,10 +215,17 @@ func NewJavaScriptFunction(in *javascriptFunctionInput, runtime *goja.Runtime) (
 			return nil, fmt.Errorf("argument %d of function %s cannot be converted to Terraform param: %w", i, in.name, err)
 		}
 
+		if arg.jsType == "Date" {
+			if sp, ok := p.(*tffunc.StringParameter); ok {
+				sp.Validators = append(sp.Validators, &tfarg.TimestampValidator{})
+			}
+		}
+
 		args[i] = JavaScriptArgument{
 			name:        arg.name,
 			description: arg.description,
 			param:       p,
+			decoder:     getArgumentDecoder(arg.jsType),
 		}
 	}
 
@@ -139,23 +240,173 @@ func NewJavaScriptFunction(in *javascriptFunctionInput, runtime *goja.Runtime) (
 	}
 
 	return &JavaScriptFunction{
-		name:        in.name,
-		summary:     in.summary,
-		description: in.description,
-		args:        args,
-		ret:         ret,
-		callable:    bindCallableToRuntime(runtime, in.callable),
+		name:                in.name,
+		summary:             in.summary,
+		description:         in.description,
+		markdownDescription: buildMarkdownDescription(in),
+		deprecationMessage:  in.deprecated,
+		pure:                in.pure,
+		conversionOptions:   in.conversion,
+		memo:                newMemoCache(maxMemoizedResults),
+		args:                args,
+		ret:                 ret,
+		callable: bindCallableToRuntime(runtime, in.callable, &callableOptions{
+			ret: tfarg.ReturnType(ret),
+			decoders: slice.Map[JavaScriptArgument, argumentDecoder](args, func(arg JavaScriptArgument) argumentDecoder {
+				return arg.decoder
+			}),
+			timeout: in.timeout,
+			values:  in.values,
+		}),
 	}, nil
 }
 
-func bindCallableToRuntime(runtime *goja.Runtime, callable goja.Callable) runtime.Callable {
+// buildMarkdownDescription renders the description of a function along
+// with the extra documentation (examples, references, etc.) as markdown.
+func buildMarkdownDescription(in *javascriptFunctionInput) string {
+	sections := make([]string, 0)
+
+	if in.description != "" {
+		sections = append(sections, in.description)
+	}
+
+	for _, example := range in.examples {
+		sections = append(sections, fmt.Sprintf("Example:\n```javascript\n%s\n```", example))
+	}
+
+	if len(in.throws) > 0 {
+		sections = append(sections, "Throws:\n- "+strings.Join(in.throws, "\n- "))
+	}
+
+	if in.since != "" {
+		sections = append(sections, fmt.Sprintf("Since: %s", in.since))
+	}
+
+	if len(in.see) > 0 {
+		sections = append(sections, "See:\n- "+strings.Join(in.see, "\n- "))
+	}
+
+	return strings.Join(sections, "\n\n")
+}
+
+// memoizationKey computes a key that uniquely identifies a list of arguments.
+//
+// The key is a hash of the arguments, so that large arguments (e.g. maps)
+// are not held in memory by the memo.
+func memoizationKey(args []any) (string, error) {
+	ctx := context.Background()
+
+	h := sha256.New()
+	for i, arg := range args {
+		val, err := arg.(attr.Value).ToTerraformValue(ctx) //nolint:forcetypeassert
+		if err != nil {
+			return "", fmt.Errorf("argument %d cannot be converted to Terraform: %w", i, err)
+		}
+
+		dv, err := tfprotov6.NewDynamicValue(val.Type(), val)
+		if err != nil {
+			return "", fmt.Errorf("argument %d cannot be serialized: %w", i, err)
+		}
+
+		// The type is not part of the msgpack encoding
+		typ := val.Type().String()
+		fmt.Fprintf(h, "%d:%s", len(typ), typ)
+		fmt.Fprintf(h, "%d:", len(dv.MsgPack))
+		h.Write(dv.MsgPack)
+	}
+
+	return string(h.Sum(nil)), nil
+}
+
+// maxMemoizedResults is how many results of a pure function are kept.
+const maxMemoizedResults = 256
+
+// memoCache holds the results of the latest calls of a pure function. When
+// it is full, the result that was used the least recently is evicted.
+type memoCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type memoEntry struct {
+	key string
+	res any
+}
+
+func newMemoCache(size int) *memoCache {
+	return &memoCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the result recorded for a key, if any.
+func (c *memoCache) get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.order.MoveToFront(e)
+
+	return e.Value.(*memoEntry).res, true //nolint:forcetypeassert
+}
+
+// add records the result for a key, evicting the oldest one if needed.
+func (c *memoCache) add(key string, res any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*memoEntry).res = res //nolint:forcetypeassert
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&memoEntry{key: key, res: res})
+
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoEntry).key) //nolint:forcetypeassert
+	}
+}
+
+// callableOptions defines how a callable is bound to a runtime.
+type callableOptions struct {
+	// ret is the type the result is converted into, whenever the value allows it.
+	ret attr.Type
+	// decoders converts the arguments that are not converted with the
+	// default conversions (e.g. binary data), by position.
+	decoders []argumentDecoder
+	// timeout interrupts the execution once it expires, if set.
+	timeout time.Duration
+	// values tunes how the values are converted between Terraform and JavaScript.
+	values tfgoja.Options
+}
+
+// bindCallableToRuntime wraps a goja callable into a callable that accepts
+// and returns Terraform values, as defined by the options.
+func bindCallableToRuntime(runtime *goja.Runtime, callable goja.Callable, opts *callableOptions) runtime.Callable {
 	ctx := context.Background()
 
 	return func(args ...any) (any, error) {
 		gojaArgs := make([]goja.Value, len(args))
 
 		for i, arg := range args {
-			res, err := tfgoja.FromTfValue(ctx, arg.(attr.Value), runtime) //nolint:forcetypeassert
+			var res goja.Value
+			var err error
+			if i < len(opts.decoders) && opts.decoders[i] != nil {
+				res, err = opts.decoders[i](ctx, arg.(attr.Value), runtime) //nolint:forcetypeassert
+			} else {
+				res, err = tfgoja.FromTfValueWithOptions(ctx, arg.(attr.Value), runtime, opts.values) //nolint:forcetypeassert
+			}
 			if err != nil {
 				return nil, fmt.Errorf("argument %d cannot be converted to Terraform: %w", i, err)
 			}
@@ -163,12 +414,40 @@ func bindCallableToRuntime(runtime *goja.Runtime, callable goja.Callable) runtim
 			gojaArgs[i] = res
 		}
 
+		// The timer might fire while the call returns, so the interruption
+		// is only made while the call is not done, and cleared after it
+		var (
+			timer *time.Timer
+			mu    sync.Mutex
+			done  bool
+		)
+		if opts.timeout > 0 {
+			timer = time.AfterFunc(opts.timeout, func() {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if !done {
+					runtime.Interrupt(fmt.Sprintf("execution timed out after %v", opts.timeout))
+				}
+			})
+		}
+
 		res, err := callable(goja.Undefined(), gojaArgs...)
+
+		if timer != nil {
+			mu.Lock()
+			done = true
+			mu.Unlock()
+
+			timer.Stop()
+			runtime.ClearInterrupt()
+		}
+
 		if err != nil {
 			return nil, fmt.Errorf("func exec: %w", err)
 		}
 
-		tfValue, err := tfgoja.ToTfValue(ctx, res, runtime)
+		tfValue, err := tfgoja.ToTfValueOfType(ctx, res, opts.ret, runtime, opts.values)
 		if err != nil {
 			return nil, fmt.Errorf("return cannot be converted to Terraform: %w", err)
 		}
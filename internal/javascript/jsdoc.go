@@ -1,20 +1,17 @@
 package javascript
 
 import (
-	"bytes"
 	"fmt"
 	"regexp"
 	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 )
 
 var (
-	jsdocRegEx            = regexp.MustCompile(`\/\*\*((?:.|\n)*?)\*\/\n(?:\$\()?(.*)`)
-	jsdocBeginRegEx       = regexp.MustCompile(`^\s?\*\s?`)
-	jsdocTagRegEx         = regexp.MustCompile(`^@(\w+)`)
-	jsdocTypeRegEx        = regexp.MustCompile(`\{(.*)\}`)
-	jsdocParamNameRegEx   = regexp.MustCompile(`\}\s+(\w+)\s+\-`)
-	jsdocDescriptionRegEx = regexp.MustCompile(`([^}]+)$`)
-	wsRegEx               = regexp.MustCompile(`\s+`)
+	jsdocBeginRegEx = regexp.MustCompile(`^\s*\*(?:\s|$)`)
+	jsdocTagRegEx   = regexp.MustCompile(`^@(\w+)\s*`)
+	jsdocNameRegEx  = regexp.MustCompile(`^(?:\[\s*([\w$.]+)(?:\s*=[^\]]*)?\s*\]|([\w$.]+))`)
 )
 
 // javaScriptArgumentMetadata holds metadata for a JavaScript argument.
@@ -32,135 +29,236 @@ type javaScriptReturnMetadata struct {
 
 // JavaScriptFunctionMetadata holds metadata for a JavaScript function.
 type JavaScriptFunctionMetadata struct {
-	summary     string
-	description string
-	params      []*javaScriptArgumentMetadata
-	returns     *javaScriptReturnMetadata
+	summary            string
+	description        string
+	params             []*javaScriptArgumentMetadata
+	returns            *javaScriptReturnMetadata
+	examples           []string
+	deprecated         bool
+	deprecationMessage string
+	throws             []string
+	since              string
+	see                []string
+}
+
+// jsdocTag represents a block tag (e.g. `@param`) found in a JSDoc comment.
+type jsdocTag struct {
+	name string
+	text string
+	line int
 }
 
-// parseScriptJSDoc parses JSDoc from a JavaScript script file.
-func parseScriptJSDoc(src string) (map[string]*JavaScriptFunctionMetadata, error) {
-	matches := jsdocRegEx.FindAllStringSubmatch(src, -1)
+// parseJSDoc parses a JSDoc string.
+//
+// The parser is tolerant: malformed or unknown tags will not fail the parsing,
+// but they will be reported as warnings. The path and line are used to point
+// the diagnostics to the exact location in the library.
+func parseJSDoc(path string, line int, doc string) (*JavaScriptFunctionMetadata, diag.Diagnostics) {
+	diags := diag.Diagnostics{}
 
-	res := make(map[string]*JavaScriptFunctionMetadata, len(matches))
+	descriptionLines := make([]string, 0)
+	tags := make([]*jsdocTag, 0)
 
-	for _, match := range matches {
-		jsdoc := match[1]
-		fnSignature := match[2]
+	var current *jsdocTag = nil
+
+	for i, raw := range strings.Split(doc, "\n") {
+		// Replace "*" and adjacent whitespace from the beginning of the line
+		raw = jsdocBeginRegEx.ReplaceAllString(raw, "")
+
+		text := strings.TrimSpace(raw)
+
+		if strings.HasPrefix(text, "@") {
+			name := ""
+			if match := jsdocTagRegEx.FindStringSubmatch(text); match != nil {
+				name = match[1]
+				text = strings.TrimPrefix(text, match[0])
+			}
+
+			current = &jsdocTag{
+				name: name,
+				text: text,
+				line: line + i,
+			}
+			tags = append(tags, current)
+
+			continue
+		}
 
-		fnHash := removeWhitespaceFromString(fnSignature)
+		if current != nil {
+			// Continuation of the previous tag. Examples keep their
+			// indentation, since they are most likely code.
+			if current.name == "example" {
+				current.text += "\n" + strings.TrimRight(raw, " \t")
+			} else if text != "" {
+				current.text = strings.TrimSpace(current.text + " " + text)
+			}
 
-		md, err := parseJSDoc(jsdoc)
-		if err != nil {
-			return nil, err
+			continue
 		}
 
-		res[fnHash] = md
+		descriptionLines = append(descriptionLines, text)
 	}
 
-	return res, nil
-}
+	md := &JavaScriptFunctionMetadata{
+		params:   make([]*javaScriptArgumentMetadata, 0),
+		examples: make([]string, 0),
+		throws:   make([]string, 0),
+		see:      make([]string, 0),
+	}
 
-// parseJSDoc parses a JSDoc string.
-func parseJSDoc(doc string) (*JavaScriptFunctionMetadata, error) {
-	lines := strings.Split(doc, "\n")
+	md.summary, md.description = splitDescription(descriptionLines)
 
-	var buf bytes.Buffer
+	for _, tag := range tags {
+		switch tag.name {
+		case "param", "arg", "argument":
+			typ, rest, err := parseTagType(tag.text)
+			if err != nil {
+				diags.Append(jsdocWarning(path, tag.line, "Malformed JSDoc tag.", "@%s: %v", tag.name, err))
+			}
 
-	params := make([]*javaScriptArgumentMetadata, 0)
-	var returns *javaScriptReturnMetadata = nil
+			match := jsdocNameRegEx.FindStringSubmatch(rest)
+			if match == nil {
+				diags.Append(jsdocWarning(path, tag.line, "Malformed JSDoc tag.", "@%s is missing the parameter name", tag.name))
+				// Keep the parameter, so the position of the following ones is not altered
+				md.params = append(md.params, &javaScriptArgumentMetadata{typ: typ})
+				continue
+			}
 
-	for _, line := range lines {
-		// Replace "*" and adjacent whitespace from the beginning of the line
-		line = jsdocBeginRegEx.ReplaceAllString(line, "")
+			arg := &javaScriptArgumentMetadata{
+				name:        match[2],
+				typ:         typ,
+				description: trimTagDescription(strings.TrimPrefix(rest, match[0])),
+			}
 
-		// Remove other whitespace
-		line = strings.TrimSpace(line)
+			// Terraform function parameters cannot be optional, so the brackets
+			// of an optional parameter (e.g. `[name]`) only surround its name
+			if match[1] != "" {
+				arg.name = match[1]
+			}
 
-		// Skip empty lines
-		if line == "" {
-			continue
-		}
+			md.params = append(md.params, arg)
+		case "return", "returns":
+			if md.returns != nil {
+				diags.Append(jsdocWarning(path, tag.line, "Duplicate JSDoc tag.", "@%s was already defined, this one will be ignored", tag.name))
+				continue
+			}
 
-		// Check for tags
-		if strings.HasPrefix(line, "@") {
-			tag, line := regExFindAndDelete(jsdocTagRegEx, line, "")
-
-			switch tag {
-			case "param":
-				var (
-					paramName        string
-					paramDescription string
-					paramType        string
-				)
-				paramName, line = regExFindAndDelete(jsdocParamNameRegEx, line, "}")
-				paramDescription, line = regExFindAndDelete(jsdocDescriptionRegEx, line, "")
-				paramType, _ = regExFindAndDelete(jsdocTypeRegEx, line, "")
-
-				params = append(params, &javaScriptArgumentMetadata{
-					name:        paramName,
-					typ:         paramType,
-					description: paramDescription,
-				})
-			case "returns":
-				var (
-					returnDescription string
-					returnType        string
-				)
-				returnDescription, line = regExFindAndDelete(jsdocDescriptionRegEx, line, "")
-				returnType, _ = regExFindAndDelete(jsdocTypeRegEx, line, "")
-
-				returns = &javaScriptReturnMetadata{
-					typ:         returnType,
-					description: returnDescription,
-				}
-			default:
-				return nil, fmt.Errorf("unknown tag: %s", tag)
+			typ, rest, err := parseTagType(tag.text)
+			if err != nil {
+				diags.Append(jsdocWarning(path, tag.line, "Malformed JSDoc tag.", "@%s: %v", tag.name, err))
 			}
-		} else {
-			// Everything else goes into the description buffer
-			buf.WriteString(line)
-			buf.WriteRune('\n')
+
+			md.returns = &javaScriptReturnMetadata{
+				typ:         typ,
+				description: trimTagDescription(rest),
+			}
+		case "example":
+			example := strings.Trim(tag.text, "\n")
+			if example == "" {
+				diags.Append(jsdocWarning(path, tag.line, "Malformed JSDoc tag.", "@%s is empty", tag.name))
+				continue
+			}
+
+			md.examples = append(md.examples, example)
+		case "deprecated":
+			md.deprecated = true
+			md.deprecationMessage = tag.text
+		case "throws", "exception":
+			typ, rest, err := parseTagType(tag.text)
+			if err != nil {
+				diags.Append(jsdocWarning(path, tag.line, "Malformed JSDoc tag.", "@%s: %v", tag.name, err))
+			}
+
+			throws := trimTagDescription(rest)
+			if typ != "" {
+				throws = strings.TrimSpace(fmt.Sprintf("`%s` %s", typ, throws))
+			}
+
+			md.throws = append(md.throws, throws)
+		case "since":
+			md.since = tag.text
+		case "see":
+			md.see = append(md.see, tag.text)
+		case "":
+			diags.Append(jsdocWarning(path, tag.line, "Malformed JSDoc tag.", "tag without a name"))
+		default:
+			diags.Append(jsdocWarning(path, tag.line, "Unknown JSDoc tag.", "@%s is not supported and it will be ignored", tag.name))
 		}
 	}
 
-	allDescription := buf.String()
+	return md, diags
+}
 
-	// First line of the description is the summary, everything else is
-	// the description itself.
-	parts := strings.SplitN(allDescription, "\n", 2)
+// splitDescription splits the free-form text of a JSDoc comment into
+// a summary and a description.
+//
+// The first paragraph of the text is the summary, everything else
+// is the description itself.
+func splitDescription(lines []string) (string, string) {
+	// Skip the empty lines at the beginning
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
 
-	var (
-		summary     string = ""
-		description string = ""
-	)
+	summary := make([]string, 0)
+	for len(lines) > 0 && lines[0] != "" {
+		summary = append(summary, lines[0])
+		lines = lines[1:]
+	}
 
-	if len(parts) == 1 {
-		summary = parts[0]
-	} else if len(parts) == 2 {
-		summary = parts[0]
-		description = parts[1]
+	description := make([]string, 0)
+	for _, line := range lines {
+		// Collapse consecutive empty lines into a single paragraph break
+		if line == "" && (len(description) == 0 || description[len(description)-1] == "") {
+			continue
+		}
+
+		description = append(description, line)
+	}
+
+	return strings.Join(summary, " "), strings.TrimSpace(strings.Join(description, "\n"))
+}
+
+// parseTagType extracts a `{type}` expression from the beginning of a tag
+// text and returns it along with the rest of the text.
+//
+// The braces are matched, so object types such as `{{a: string;}}` are
+// kept intact. If the text does not start with a type, the type is empty.
+func parseTagType(s string) (string, string, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{") {
+		return "", s, nil
 	}
 
-	return &JavaScriptFunctionMetadata{
-		summary:     summary,
-		description: description,
-		params:      params,
-		returns:     returns,
-	}, nil
+	depth := 0
+	for i, c := range s {
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		}
+
+		if depth == 0 {
+			return strings.TrimSpace(s[1:i]), strings.TrimSpace(s[i+1:]), nil
+		}
+	}
+
+	return "", "", fmt.Errorf("type expression '%s' is not closed", s)
 }
 
-// regExFindAndDelete find the match of regex in a given string
-// then removes the match from the string.
-func regExFindAndDelete(re *regexp.Regexp, s string, putback string) (string, string) {
-	match := re.FindStringSubmatch(s)
-	return strings.TrimSpace(match[1]), re.ReplaceAllString(s, putback)
+// trimTagDescription removes the optional hyphen that separates
+// the tag name (or type) from its description.
+func trimTagDescription(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "-")
+	return strings.TrimSpace(s)
 }
 
-// removeWhitespaceFromString removes any whitespace character from
-// a given string keeping everything else as it is.
-//
-// Example: "this is an example" => "thisisanexample".
-func removeWhitespaceFromString(s string) string {
-	return wsRegEx.ReplaceAllString(s, "")
+// jsdocWarning creates a warning diagnostic pointing to a line of a library.
+func jsdocWarning(path string, line int, summary string, format string, args ...any) diag.Diagnostic {
+	return diag.NewWarningDiagnostic(
+		summary,
+		fmt.Sprintf("%s:%d: %s.", path, line, fmt.Sprintf(format, args...)),
+	)
 }
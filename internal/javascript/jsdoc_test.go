@@ -0,0 +1,175 @@
+package javascript
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseJSDoc(t *testing.T) {
+	tests := []struct {
+		name     string
+		given    string
+		want     *JavaScriptFunctionMetadata
+		warnings []string
+	}{
+		{
+			name: "Summary and description",
+			given: `
+			 * Adds two numbers together.
+			 *
+			 * Adds two numbers and returns
+			 * the sum of the numbers.
+			 `,
+			want: &JavaScriptFunctionMetadata{
+				summary:     "Adds two numbers together.",
+				description: "Adds two numbers and returns\nthe sum of the numbers.",
+			},
+		},
+		{
+			name: "Parameters with and without hyphen",
+			given: `
+			 * @param {number} a - The first number.
+			 * @param {number} b The second number.
+			 * @param {string} c
+			 `,
+			want: &JavaScriptFunctionMetadata{
+				params: []*javaScriptArgumentMetadata{
+					{name: "a", typ: "number", description: "The first number."},
+					{name: "b", typ: "number", description: "The second number."},
+					{name: "c", typ: "string"},
+				},
+			},
+		},
+		{
+			name: "Optional parameters",
+			given: `
+			 * @param {number} [a] - The first number.
+			 * @param {number} [b=10] - The second number.
+			 `,
+			want: &JavaScriptFunctionMetadata{
+				params: []*javaScriptArgumentMetadata{
+					{name: "a", typ: "number", description: "The first number."},
+					{name: "b", typ: "number", description: "The second number."},
+				},
+			},
+		},
+		{
+			name: "Object types with nested braces",
+			given: `
+			 * @param {{name: string; age: number;}} person - The person.
+			 * @returns {{name: string;}} The name.
+			 `,
+			want: &JavaScriptFunctionMetadata{
+				params: []*javaScriptArgumentMetadata{
+					{name: "person", typ: "{name: string; age: number;}", description: "The person."},
+				},
+				returns: &javaScriptReturnMetadata{typ: "{name: string;}", description: "The name."},
+			},
+		},
+		{
+			name: "Multi-line tag descriptions",
+			given: `
+			 * @param {string} s - The string
+			 *   that will be searched.
+			 * @return {boolean} Whether the string
+			 *   matched or not.
+			 `,
+			want: &JavaScriptFunctionMetadata{
+				params: []*javaScriptArgumentMetadata{
+					{name: "s", typ: "string", description: "The string that will be searched."},
+				},
+				returns: &javaScriptReturnMetadata{typ: "boolean", description: "Whether the string matched or not."},
+			},
+		},
+		{
+			name: "Standard documentation tags",
+			given: `
+			 * @example
+			 * sum(1, 2)
+			 *   // => 3
+			 * @deprecated use sum_v2
+			 * @throws {TypeError} If the inputs are not numbers.
+			 * @since 1.2.0
+			 * @see sum_v2
+			 `,
+			want: &JavaScriptFunctionMetadata{
+				examples:           []string{"sum(1, 2)\n  // => 3"},
+				deprecated:         true,
+				deprecationMessage: "use sum_v2",
+				throws:             []string{"`TypeError` If the inputs are not numbers."},
+				since:              "1.2.0",
+				see:                []string{"sum_v2"},
+			},
+		},
+		{
+			name: "Unknown tags are warnings",
+			given: `
+			 * Summary.
+			 * @customtag something
+			 `,
+			want: &JavaScriptFunctionMetadata{
+				summary: "Summary.",
+			},
+			warnings: []string{"lib.js:3: @customtag is not supported and it will be ignored."},
+		},
+		{
+			name: "Malformed parameters are warnings",
+			given: `
+			 * @param {number} - The first number.
+			 * @param {number b - The second number.
+			 `,
+			want: &JavaScriptFunctionMetadata{
+				params: []*javaScriptArgumentMetadata{
+					{typ: "number"},
+					{},
+				},
+			},
+			warnings: []string{
+				"lib.js:2: @param is missing the parameter name.",
+				"lib.js:3: @param: type expression '{number b - The second number.' is not closed.",
+				"lib.js:3: @param is missing the parameter name.",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, diags := parseJSDoc("lib.js", 1, test.given)
+
+			if diags.HasError() {
+				t.Fatalf("parsing failed with errors: %v", diags)
+			}
+
+			warnings := make([]string, 0, len(diags))
+			for _, d := range diags {
+				warnings = append(warnings, d.Detail())
+			}
+
+			if test.warnings == nil {
+				test.warnings = []string{}
+			}
+
+			if !reflect.DeepEqual(warnings, test.warnings) {
+				t.Errorf("wrong warnings received:\nwant: %q\ngot : %q", test.warnings, warnings)
+			}
+
+			want := test.want
+			if want.params == nil {
+				want.params = []*javaScriptArgumentMetadata{}
+			}
+			if want.examples == nil {
+				want.examples = []string{}
+			}
+			if want.throws == nil {
+				want.throws = []string{}
+			}
+			if want.see == nil {
+				want.see = []string{}
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("wrong metadata received:\nwant: %+v\ngot : %+v", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package javascript
+
+import (
+	"fmt"
+	"math"
+	"terraform-provider-func/tftypes/tfconvert"
+	"terraform-provider-func/tftypes/tfgoja"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// registrationOptions holds the options that can be passed to `$`
+// alongside the function that is registered:
+//
+//	$(fn, { name, summary, description, params, returns, deprecated, pure, timeout,
+//	        extraAttributes, missingAttributes, numbers, collections, dates })
+//
+// Any option that is set takes precedence over the JSDoc of the function.
+type registrationOptions struct {
+	name               string
+	summary            string
+	description        string
+	params             []*javaScriptArgumentMetadata
+	returns            *javaScriptReturnMetadata
+	deprecated         *bool
+	deprecationMessage string
+	pure               bool
+	timeout            time.Duration
+	conversion         tfconvert.Options
+	values             tfgoja.Options
+}
+
+// applyTo overrides the metadata of a function with the options that were set.
+func (o *registrationOptions) applyTo(md *JavaScriptFunctionMetadata) {
+	if o.summary != "" {
+		md.summary = o.summary
+	}
+
+	if o.description != "" {
+		md.description = o.description
+	}
+
+	if o.params != nil {
+		md.params = o.params
+	}
+
+	if o.returns != nil {
+		md.returns = o.returns
+	}
+
+	if o.deprecated != nil {
+		md.deprecated = *o.deprecated
+		md.deprecationMessage = o.deprecationMessage
+	}
+}
+
+func (r *JavaScriptRuntime) registerFn() func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		target := call.Argument(0)
+
+		if goja.IsUndefined(target) || goja.IsNull(target) {
+			panic(r.vm.ToValue("$() requires a function: received nothing"))
+		}
+
+		if fn, ok := goja.AssertFunction(target); ok {
+			opts, err := parseRegistrationOptions(call.Argument(1))
+			if err != nil {
+				panic(r.vm.ToValue(fmt.Sprintf("$() received invalid options: %v", err)))
+			}
+
+			if err := r.register(target, fn, opts); err != nil {
+				panic(r.vm.ToValue(err.Error()))
+			}
+
+			return goja.Undefined()
+		}
+
+		obj, ok := target.(*goja.Object)
+		if !ok {
+			panic(r.vm.ToValue("$() requires a function or an object of functions: did not receive any"))
+		}
+
+		// An object of functions: every key is the name of a function
+		for _, key := range obj.Keys() {
+			value := obj.Get(key)
+
+			fn, ok := goja.AssertFunction(value)
+			if !ok {
+				panic(r.vm.ToValue(fmt.Sprintf("$() requires an object of functions: property '%s' is not a function", key)))
+			}
+
+			if err := r.register(value, fn, &registrationOptions{name: key}); err != nil {
+				panic(r.vm.ToValue(err.Error()))
+			}
+		}
+
+		return goja.Undefined()
+	}
+}
+
+// register adds a function to the runtime.
+//
+// The name of the function is taken from the options, or from the
+// function itself if the options do not set any.
+func (r *JavaScriptRuntime) register(value goja.Value, fn goja.Callable, opts *registrationOptions) error {
+	obj := value.ToObject(r.vm)
+
+	if opts.name == "" {
+		opts.name = obj.Get("name").String()
+	}
+
+	if opts.name == "" {
+		return fmt.Errorf("registered function must have a name")
+	}
+
+	f, err := r.parseFunction(fn, obj.String(), opts)
+	if err != nil {
+		return err
+	}
+
+	r.funcs[opts.name] = f
+
+	return nil
+}
+
+// parseRegistrationOptions reads the options object passed to `$`.
+func parseRegistrationOptions(v goja.Value) (*registrationOptions, error) {
+	opts := &registrationOptions{}
+
+	if goja.IsUndefined(v) || goja.IsNull(v) {
+		return opts, nil
+	}
+
+	raw, ok := v.Export().(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("options must be an object")
+	}
+
+	for key, value := range raw {
+		var err error
+
+		switch key {
+		case "name":
+			opts.name, err = asString(key, value)
+		case "summary":
+			opts.summary, err = asString(key, value)
+		case "description":
+			opts.description, err = asString(key, value)
+		case "params":
+			opts.params, err = parseParamsOption(value)
+		case "returns":
+			opts.returns, err = parseReturnsOption(value)
+		case "deprecated":
+			switch d := value.(type) {
+			case bool:
+				opts.deprecated = &d
+			case string:
+				deprecated := true
+				opts.deprecated = &deprecated
+				opts.deprecationMessage = d
+			default:
+				err = fmt.Errorf("'deprecated' must be a boolean or a string")
+			}
+		case "pure":
+			pure, ok := value.(bool)
+			if !ok {
+				err = fmt.Errorf("'pure' must be a boolean")
+			}
+			opts.pure = pure
+		case "timeout":
+			var ms float64
+			switch t := value.(type) {
+			case int64:
+				ms = float64(t)
+			case float64:
+				ms = t
+			default:
+				err = fmt.Errorf("'timeout' must be a number of milliseconds")
+			}
+
+			switch {
+			case math.IsNaN(ms) || math.IsInf(ms, 0):
+				err = fmt.Errorf("'timeout' must be a finite number of milliseconds")
+			case ms < 0:
+				err = fmt.Errorf("'timeout' cannot be negative")
+			case ms > float64(math.MaxInt64/int64(time.Millisecond)):
+				err = fmt.Errorf("'timeout' is too large")
+			}
+
+			opts.timeout = time.Duration(ms * float64(time.Millisecond))
+		case "extraAttributes":
+			switch value {
+			case "drop":
+				opts.conversion.DropExtraAttributes = true
+			case "fail":
+				opts.conversion.DropExtraAttributes = false
+			default:
+				err = fmt.Errorf("'extraAttributes' must be either \"drop\" or \"fail\"")
+			}
+		case "numbers":
+			switch value {
+			case "bigint":
+				opts.values.Numbers = tfgoja.NumberBigInt
+			case "string":
+				opts.values.Numbers = tfgoja.NumberString
+			default:
+				err = fmt.Errorf("'numbers' must be either \"bigint\" or \"string\"")
+			}
+		case "collections":
+			switch value {
+			case "plain":
+				opts.values.Collections = tfgoja.CollectionPlain
+			case "native":
+				opts.values.Collections = tfgoja.CollectionNative
+			default:
+				err = fmt.Errorf("'collections' must be either \"plain\" or \"native\"")
+			}
+		case "dates":
+			switch value {
+			case "seconds":
+				opts.values.Dates = tfgoja.DateSeconds
+			case "milliseconds":
+				opts.values.Dates = tfgoja.DateMilliseconds
+			default:
+				err = fmt.Errorf("'dates' must be either \"seconds\" or \"milliseconds\"")
+			}
+		case "missingAttributes":
+			switch value {
+			case "null":
+				opts.conversion.NullMissingAttributes = true
+			case "fail":
+				opts.conversion.NullMissingAttributes = false
+			default:
+				err = fmt.Errorf("'missingAttributes' must be either \"null\" or \"fail\"")
+			}
+		default:
+			err = fmt.Errorf("unknown option '%s'", key)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return opts, nil
+}
+
+// parseParamsOption reads the `params` option.
+//
+// Each parameter can either be a string (the parameter name) or
+// an object like `{ name, type, description }`.
+func parseParamsOption(value any) ([]*javaScriptArgumentMetadata, error) {
+	list, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("'params' must be an array")
+	}
+
+	params := make([]*javaScriptArgumentMetadata, len(list))
+	for i, item := range list {
+		param := &javaScriptArgumentMetadata{typ: "any"}
+
+		switch p := item.(type) {
+		case string:
+			param.name = p
+		case map[string]any:
+			var err error
+
+			if param.name, err = asString("name", p["name"]); err != nil {
+				return nil, fmt.Errorf("params[%d]: %w", i, err)
+			}
+
+			if t, ok := p["type"]; ok {
+				if param.typ, err = asString("type", t); err != nil {
+					return nil, fmt.Errorf("params[%d]: %w", i, err)
+				}
+			}
+
+			if d, ok := p["description"]; ok {
+				if param.description, err = asString("description", d); err != nil {
+					return nil, fmt.Errorf("params[%d]: %w", i, err)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("params[%d] must be a string or an object", i)
+		}
+
+		if param.name == "" {
+			return nil, fmt.Errorf("params[%d] must have a name", i)
+		}
+
+		params[i] = param
+	}
+
+	return params, nil
+}
+
+// parseReturnsOption reads the `returns` option.
+//
+// It can either be a string (the return type) or an object
+// like `{ type, description }`.
+func parseReturnsOption(value any) (*javaScriptReturnMetadata, error) {
+	switch r := value.(type) {
+	case string:
+		return &javaScriptReturnMetadata{typ: r}, nil
+	case map[string]any:
+		ret := &javaScriptReturnMetadata{typ: "any"}
+
+		var err error
+		if t, ok := r["type"]; ok {
+			if ret.typ, err = asString("type", t); err != nil {
+				return nil, fmt.Errorf("returns: %w", err)
+			}
+		}
+
+		if d, ok := r["description"]; ok {
+			if ret.description, err = asString("description", d); err != nil {
+				return nil, fmt.Errorf("returns: %w", err)
+			}
+		}
+
+		return ret, nil
+	default:
+		return nil, fmt.Errorf("'returns' must be a string or an object")
+	}
+}
+
+// asString asserts that an exported option value is a string.
+func asString(key string, value any) (string, error) {
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("'%s' must be a string", key)
+	}
+
+	return s, nil
+}
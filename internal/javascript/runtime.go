@@ -2,26 +2,26 @@ package javascript
 
 import (
 	"fmt"
-	"regexp"
-	"strings"
 	"terraform-provider-func/internal/runtime"
 
 	"github.com/dop251/goja"
 	"github.com/dop251/goja_nodejs/console"
 	"github.com/dop251/goja_nodejs/process"
 	"github.com/dop251/goja_nodejs/require"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 )
 
-var (
-	argNamesRegEx = regexp.MustCompile(`\(([^)]*)\)`)
+const (
+	defaultDeprecationMessage string = "This function is deprecated."
 )
 
 // JavaScriptRuntime is a concrete implementation of the Runtime interface
 // and manages a runtime for JavaScript using the goja project.
 type JavaScriptRuntime struct {
-	vm           *goja.Runtime
-	funcMetadata map[string]*JavaScriptFunctionMetadata
-	funcs        map[string]*JavaScriptFunction
+	vm          *goja.Runtime
+	funcSources map[string][]*javaScriptFunctionSource
+	funcs       map[string]*JavaScriptFunction
+	registered  map[string]int
 }
 
 // New creates a new JavaScriptRuntime.
@@ -35,9 +35,10 @@ func New() runtime.Runtime {
 
 	// Create the runti,e
 	runtime := &JavaScriptRuntime{
-		vm:           vm,
-		funcs:        make(map[string]*JavaScriptFunction, 0),
-		funcMetadata: make(map[string]*JavaScriptFunctionMetadata, 0),
+		vm:          vm,
+		funcs:       make(map[string]*JavaScriptFunction, 0),
+		funcSources: make(map[string][]*javaScriptFunctionSource, 0),
+		registered:  make(map[string]int, 0),
 	}
 
 	// Define a global function `$` that registers functions
@@ -59,116 +60,122 @@ func (r *JavaScriptRuntime) Functions() []runtime.Function {
 	return fns
 }
 
-func (r *JavaScriptRuntime) Parse(src string) error {
-	metadata, err := parseScriptJSDoc(src)
-	if err != nil {
-		return fmt.Errorf("cannot parse jsdoc: %w", err)
+func (r *JavaScriptRuntime) Parse(path string, src string) diag.Diagnostics {
+	sources, diags := parseScriptFunctions(path, src)
+	if diags.HasError() {
+		return diags
 	}
 
-	for k, v := range metadata {
-		r.funcMetadata[k] = v
+	for k, v := range sources {
+		r.funcSources[k] = append(r.funcSources[k], v...)
 	}
 
 	if _, err := r.vm.RunString(src); err != nil {
-		return err
+		diags.AddError("Cannot run library.", fmt.Sprintf("%s: %v.", path, err))
 	}
 
-	return nil
+	return diags
 }
 
-func (r *JavaScriptRuntime) registerFn() func(goja.FunctionCall) goja.Value {
-	return func(call goja.FunctionCall) goja.Value {
-		fnRaw := call.Argument(0)
-
-		if goja.IsUndefined(fnRaw) || goja.IsNull(fnRaw) {
-			panic(r.vm.ToValue("$() requires a function: received nothing"))
-		}
-
-		fn, ok := goja.AssertFunction(fnRaw)
-		if !ok {
-			panic(r.vm.ToValue("$() requires a function: did not receive a function"))
-		}
-
-		fnName := fnRaw.ToObject(r.vm).Get("name").String()
-		if fnName == "" {
-			panic(r.vm.ToValue("Registered function must have a name"))
-		}
-
-		fnStr := fnRaw.ToObject(r.vm).String()
-
-		f, err := r.parseFunction(fnName, fn, fnStr)
-		if err != nil {
-			panic(r.vm.ToValue(err))
-		}
-
-		r.funcs[fnName] = f
-
-		return goja.Undefined()
-	}
-}
-
-func (r *JavaScriptRuntime) parseFunction(name string, fn goja.Callable, fnStr string) (*JavaScriptFunction, error) {
-	fnSignature := strings.SplitN(fnStr, "\n", 2)
-	fnHash := removeWhitespaceFromString(fnSignature[0])
-
-	argNames, err := extractArgNames(fnStr)
+func (r *JavaScriptRuntime) parseFunction(fn goja.Callable, fnStr string, opts *registrationOptions) (*JavaScriptFunction, error) {
+	fnSrc, err := r.lookupFunctionSource(fnStr)
 	if err != nil {
-		return nil, fmt.Errorf("could no extract argument names from function %s: %w", name, err)
+		return nil, fmt.Errorf("could no extract argument names from function %s: %w", opts.name, err)
 	}
 
-	summary := ""
-	description := ""
-
-	var args []javaScriptArgumentInput = make([]javaScriptArgumentInput, len(argNames))
-	for i, argName := range argNames {
-		args[i].name = argName
-		args[i].jsType = "any"
-		args[i].description = ""
+	metadata := &JavaScriptFunctionMetadata{}
+	if fnSrc.metadata != nil {
+		// Copy the metadata, so the options do not alter the JSDoc of
+		// other functions that share the same source
+		md := *fnSrc.metadata
+		metadata = &md
 	}
 
-	returnType := "any"
-
-	metadata, ok := r.funcMetadata[fnHash]
-	if ok {
-		summary = metadata.summary
-		description = metadata.description
+	opts.applyTo(metadata)
 
+	var args []javaScriptArgumentInput
+	if opts.params != nil {
+		// Explicit parameters take precedence over the function signature
+		args = make([]javaScriptArgumentInput, len(metadata.params))
 		for i, param := range metadata.params {
 			args[i].name = param.name
+			args[i].jsType = param.typ
+			args[i].description = param.description
+		}
+	} else {
+		args = make([]javaScriptArgumentInput, len(fnSrc.argNames))
+		for i, argName := range fnSrc.argNames {
+			args[i].name = argName
+			args[i].jsType = "any"
+			args[i].description = ""
+		}
+
+		for i, param := range metadata.params {
+			if i >= len(args) {
+				// The JSDoc documents more parameters than the function has
+				break
+			}
+
+			if param.name != "" {
+				args[i].name = param.name
+			}
+
 			args[i].description = param.description
 			args[i].jsType = param.typ
 		}
+	}
 
+	returnType := "any"
+	if metadata.returns != nil {
 		returnType = metadata.returns.typ
 	}
 
+	deprecated := ""
+	if metadata.deprecated {
+		deprecated = metadata.deprecationMessage
+		if deprecated == "" {
+			deprecated = defaultDeprecationMessage
+		}
+	}
+
 	return NewJavaScriptFunction(&javascriptFunctionInput{
-		name:        name,
-		summary:     summary,
-		description: description,
+		name:        opts.name,
+		summary:     metadata.summary,
+		description: metadata.description,
 		args:        args,
 		retJsType:   returnType,
 		callable:    fn,
+		examples:    metadata.examples,
+		throws:      metadata.throws,
+		since:       metadata.since,
+		see:         metadata.see,
+		deprecated:  deprecated,
+		pure:        opts.pure,
+		timeout:     opts.timeout,
+		conversion:  opts.conversion,
+		values:      opts.values,
 	}, r.vm)
 }
 
-func extractArgNames(fnString string) ([]string, error) {
-	matches := argNamesRegEx.FindStringSubmatch(fnString)
-	if len(matches) < 2 {
-		return nil, fmt.Errorf("no arguments found in function signature")
+// lookupFunctionSource finds what is statically known about a function,
+// based on its source code.
+//
+// If multiple functions share the exact same source, they are matched in
+// the order they were registered, which is also the order they were declared.
+func (r *JavaScriptRuntime) lookupFunctionSource(fnStr string) (*javaScriptFunctionSource, error) {
+	candidates := r.funcSources[fnStr]
+	if len(candidates) == 0 {
+		// The function was not part of any parsed library
+		return parseFunctionSource(fnStr)
 	}
 
-	argNames := strings.Split(matches[1], ",")
-	for i, arg := range argNames {
-		argNames[i] = strings.TrimSpace(arg)
+	i := r.registered[fnStr]
+	if i >= len(candidates) {
+		// Registered more times than declared, reuse the last declaration
+		i = len(candidates) - 1
 	}
 
-	var filteredArgs []string
-	for _, arg := range argNames {
-		if arg != "" {
-			filteredArgs = append(filteredArgs, arg)
-		}
-	}
+	r.registered[fnStr] = i + 1
 
-	return filteredArgs, nil
+	return candidates[i], nil
 }
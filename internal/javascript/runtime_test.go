@@ -0,0 +1,665 @@
+package javascript
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"terraform-provider-func/internal/runtime"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	tffunc "github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// parseLibrary parses a library into a new runtime and returns its
+// functions indexed by name.
+func parseLibrary(t *testing.T, src string) map[string]runtime.Function {
+	t.Helper()
+
+	r := New()
+	if diags := r.Parse("lib.js", src); diags.HasError() {
+		t.Fatalf("library could not be parsed: %v", diags)
+	}
+
+	funcs := make(map[string]runtime.Function)
+	for _, f := range r.Functions() {
+		funcs[f.Name()] = f
+	}
+
+	return funcs
+}
+
+func TestDeprecation(t *testing.T) {
+	funcs := parseLibrary(t, `
+/**
+ * Sums two numbers.
+ * @deprecated use sum_v2
+ */
+$(function sum(a, b) {
+  return a + b;
+})
+
+/**
+ * Sums two numbers.
+ * @deprecated
+ */
+$(function sum_v1(a, b) {
+  return a + b;
+})
+
+/**
+ * Sums two numbers.
+ */
+$(function sum_v2(a, b) {
+  return a + b;
+})
+`)
+
+	tests := map[string]string{
+		"sum":    "use sum_v2",
+		"sum_v1": defaultDeprecationMessage,
+		"sum_v2": "",
+	}
+
+	for name, want := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, ok := funcs[name]
+			if !ok {
+				t.Fatalf("function %s was not registered", name)
+			}
+
+			if got := f.DeprecationMessage(); got != want {
+				t.Errorf("wrong deprecation message:\nwant: %q\ngot : %q", want, got)
+			}
+
+			resp := &tffunc.DefinitionResponse{}
+			runtime.TerraformFunction{Function: f}.Definition(context.Background(), tffunc.DefinitionRequest{}, resp)
+
+			if got := resp.Definition.DeprecationMessage; got != want {
+				t.Errorf("wrong definition deprecation message:\nwant: %q\ngot : %q", want, got)
+			}
+		})
+	}
+}
+
+func TestRegistrationOptions(t *testing.T) {
+	funcs := parseLibrary(t, `
+/**
+ * Multiplies two numbers.
+ * @param {number} a - The first number.
+ * @param {number} b - The second number.
+ * @returns {number} The product.
+ */
+$(function multiply(a, b) {
+  return a * b;
+}, { name: "product", summary: "Product of two numbers." })
+
+$((s) => s.length, {
+  name: "length",
+  params: [{ name: "s", type: "string", description: "The string." }],
+  returns: { type: "number", description: "The length of the string." },
+  deprecated: "use strlen",
+})
+
+$(x => x, { name: "identity" })
+
+const helpers = {
+  upper: function (s) { return s.toUpperCase(); },
+  lower: (s) => s.toLowerCase(),
+};
+$(helpers)
+`)
+
+	tests := []struct {
+		name       string
+		summary    string
+		params     []string
+		ret        attr.Type
+		deprecated string
+	}{
+		{"product", "Product of two numbers.", []string{"a", "b"}, basetypes.NumberType{}, ""},
+		{"length", "", []string{"s"}, basetypes.NumberType{}, "use strlen"},
+		{"identity", "", []string{"x"}, basetypes.DynamicType{}, ""},
+		{"upper", "", []string{"s"}, basetypes.DynamicType{}, ""},
+		{"lower", "", []string{"s"}, basetypes.DynamicType{}, ""},
+	}
+
+	if len(funcs) != len(tests) {
+		t.Errorf("wrong number of functions registered: want %d, got %d", len(tests), len(funcs))
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f, ok := funcs[test.name]
+			if !ok {
+				t.Fatalf("function %s was not registered", test.name)
+			}
+
+			if f.Summary() != test.summary {
+				t.Errorf("wrong summary:\nwant: %q\ngot : %q", test.summary, f.Summary())
+			}
+
+			if f.DeprecationMessage() != test.deprecated {
+				t.Errorf("wrong deprecation message:\nwant: %q\ngot : %q", test.deprecated, f.DeprecationMessage())
+			}
+
+			params, _ := f.TerraformParameters()
+			names := make([]string, len(params))
+			for i, p := range params {
+				names[i] = p.GetName()
+			}
+
+			if !reflect.DeepEqual(names, test.params) {
+				t.Errorf("wrong parameters:\nwant: %v\ngot : %v", test.params, names)
+			}
+
+			ret, _ := f.TerraformReturn()
+			if !ret.GetType().Equal(test.ret) {
+				t.Errorf("wrong return type:\nwant: %v\ngot : %v", test.ret, ret.GetType())
+			}
+		})
+	}
+}
+
+func TestRegistrationInvalidOptions(t *testing.T) {
+	tests := map[string]string{
+		"Unknown option":      `$(function f(a) { return a; }, { nmae: "g" })`,
+		"Invalid timeout":     `$(function f(a) { return a; }, { timeout: "1s" })`,
+		"Infinite timeout":    `$(function f(a) { return a; }, { timeout: Infinity })`,
+		"NaN timeout":         `$(function f(a) { return a; }, { timeout: NaN })`,
+		"Unnamed parameter":   `$(function f(a) { return a; }, { params: [{ type: "string" }] })`,
+		"Anonymous function":  `$((a) => a)`,
+		"Not a function":      `$({ f: 1 })`,
+		"Invalid policy":      `$(function f(a) { return a; }, { extraAttributes: "keep" })`,
+		"Invalid numbers":     `$(function f(a) { return a; }, { numbers: "float" })`,
+		"Invalid collections": `$(function f(a) { return a; }, { collections: "json" })`,
+		"Invalid dates":       `$(function f(a) { return a; }, { dates: "nanoseconds" })`,
+	}
+
+	for name, src := range tests {
+		t.Run(name, func(t *testing.T) {
+			if diags := New().Parse("lib.js", src); !diags.HasError() {
+				t.Errorf("library was expected to fail")
+			}
+		})
+	}
+}
+
+func TestRegistrationTimeout(t *testing.T) {
+	funcs := parseLibrary(t, `
+$(function spin(a) {
+  while (true) {}
+}, { timeout: 50 })
+
+$(function fast(a) {
+  return a;
+}, { timeout: 50 })
+`)
+
+	_, err := funcs["spin"].Execute(basetypes.NewNumberValue(big.NewFloat(1)))
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("function was expected to time out, got: %v", err)
+	}
+
+	// The runtime must still be usable after an interruption
+	res, err := funcs["fast"].Execute(basetypes.NewNumberValue(big.NewFloat(1)))
+	if err != nil {
+		t.Fatalf("function was not expected to fail: %v", err)
+	}
+
+	if !res.(attr.Value).Equal(basetypes.NewNumberValue(big.NewFloat(1))) { //nolint:forcetypeassert
+		t.Errorf("wrong result: %v", res)
+	}
+}
+
+func TestRegistrationTimeoutRace(t *testing.T) {
+	funcs := parseLibrary(t, `
+$(function edge(a) {
+  const start = Date.now();
+  while (Date.now() - start < 1) {}
+  return a;
+}, { timeout: 1 })
+
+$(function fast(a) {
+  return a;
+})
+`)
+
+	// A timeout firing while the call returns must not interrupt the next one
+	for i := 0; i < 200; i++ {
+		_, _ = funcs["edge"].Execute(basetypes.NewNumberValue(big.NewFloat(1)))
+
+		if _, err := funcs["fast"].Execute(basetypes.NewNumberValue(big.NewFloat(1))); err != nil {
+			t.Fatalf("function was not expected to fail after %d calls: %v", i, err)
+		}
+	}
+}
+
+func TestRegistrationPure(t *testing.T) {
+	funcs := parseLibrary(t, `
+let calls = 0;
+
+$(function counted(a) {
+  calls++;
+  return calls;
+}, { pure: true })
+
+$(function uncounted(a) {
+  calls++;
+  return calls;
+})
+`)
+
+	arg := basetypes.NewStringValue("a")
+
+	first, _ := funcs["counted"].Execute(arg)
+	second, _ := funcs["counted"].Execute(arg)
+	if !first.(attr.Value).Equal(second.(attr.Value)) { //nolint:forcetypeassert
+		t.Errorf("pure function results were not reused: %v =/= %v", first, second)
+	}
+
+	other, _ := funcs["counted"].Execute(basetypes.NewStringValue("b"))
+	if first.(attr.Value).Equal(other.(attr.Value)) { //nolint:forcetypeassert
+		t.Errorf("pure function reused the result of different arguments")
+	}
+
+	first, _ = funcs["uncounted"].Execute(arg)
+	second, _ = funcs["uncounted"].Execute(arg)
+	if first.(attr.Value).Equal(second.(attr.Value)) { //nolint:forcetypeassert
+		t.Errorf("impure function results were reused")
+	}
+}
+
+func TestMemoCache(t *testing.T) {
+	memo := newMemoCache(2)
+
+	memo.add("a", 1)
+	memo.add("b", 2)
+
+	// Using a makes b the least recently used result
+	if res, ok := memo.get("a"); !ok || res != 1 {
+		t.Fatalf("the result of a was expected to be kept, got %v", res)
+	}
+
+	memo.add("c", 3)
+
+	if _, ok := memo.get("b"); ok {
+		t.Errorf("the result of b was expected to be evicted")
+	}
+
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if res, ok := memo.get(key); !ok || res != want {
+			t.Errorf("the result of %s was expected to be kept, got %v", key, res)
+		}
+	}
+}
+
+func TestTupleTypes(t *testing.T) {
+	ctx := context.Background()
+
+	funcs := parseLibrary(t, `
+/**
+ * Swaps the elements of a pair.
+ * @param {[string, number]} pair - The pair.
+ * @returns {[number, string]} The swapped pair.
+ */
+$(function swap(pair) {
+  return [pair[1], pair[0]];
+})
+
+/**
+ * Returns a malformed pair.
+ * @returns {[number, string]} The pair.
+ */
+$(function malformed() {
+  return [1];
+})
+`)
+
+	fn := runtime.TerraformFunction{Function: funcs["swap"]}
+
+	def := &tffunc.DefinitionResponse{}
+	fn.Definition(ctx, tffunc.DefinitionRequest{}, def)
+	if def.Diagnostics.HasError() {
+		t.Fatalf("definition failed: %v", def.Diagnostics)
+	}
+
+	if _, ok := def.Definition.Parameters[0].(*tffunc.DynamicParameter); !ok {
+		t.Errorf("tuple parameter was expected to be dynamic, got %T", def.Definition.Parameters[0])
+	}
+
+	if _, ok := def.Definition.Return.GetType().(basetypes.DynamicType); !ok {
+		t.Errorf("tuple return was expected to be dynamic, got %T", def.Definition.Return.GetType())
+	}
+
+	pair := basetypes.NewTupleValueMust(
+		[]attr.Type{basetypes.StringType{}, basetypes.NumberType{}},
+		[]attr.Value{basetypes.NewStringValue("a"), basetypes.NewNumberValue(big.NewFloat(1))},
+	)
+
+	resp := &tffunc.RunResponse{Result: tffunc.NewResultData(basetypes.NewDynamicUnknown())}
+	fn.Run(ctx, tffunc.RunRequest{
+		Arguments: tffunc.NewArgumentsData([]attr.Value{basetypes.NewDynamicValue(pair)}),
+	}, resp)
+
+	if resp.Error != nil {
+		t.Fatalf("function was not expected to fail: %v", resp.Error)
+	}
+
+	want := basetypes.NewDynamicValue(basetypes.NewTupleValueMust(
+		[]attr.Type{basetypes.NumberType{}, basetypes.StringType{}},
+		[]attr.Value{basetypes.NewNumberValue(big.NewFloat(1)), basetypes.NewStringValue("a")},
+	))
+
+	if got := resp.Result.Value(); !got.Equal(want) {
+		t.Errorf("wrong result:\nwant: %v\ngot : %v", want, got)
+	}
+
+	resp = &tffunc.RunResponse{Result: tffunc.NewResultData(basetypes.NewDynamicUnknown())}
+	runtime.TerraformFunction{Function: funcs["malformed"]}.Run(ctx, tffunc.RunRequest{
+		Arguments: tffunc.NewArgumentsData([]attr.Value{}),
+	}, resp)
+
+	if resp.Error == nil {
+		t.Errorf("function returning the wrong tuple was expected to fail")
+	}
+}
+
+func TestStringEncodedReturns(t *testing.T) {
+	funcs := parseLibrary(t, `
+/**
+ * Rounds a number to two decimals.
+ * @param {number} n - The number.
+ * @returns {number} The rounded number.
+ */
+$(function round(n) {
+  return n.toFixed(2);
+})
+`)
+
+	resp := &tffunc.RunResponse{Result: tffunc.NewResultData(basetypes.NewNumberUnknown())}
+	runtime.TerraformFunction{Function: funcs["round"]}.Run(context.Background(), tffunc.RunRequest{
+		Arguments: tffunc.NewArgumentsData([]attr.Value{basetypes.NewNumberValue(big.NewFloat(3.14159))}),
+	}, resp)
+
+	if resp.Error != nil {
+		t.Fatalf("function was not expected to fail: %v", resp.Error)
+	}
+
+	// String-encoded numbers are parsed with the full precision of Terraform numbers
+	f, _, _ := big.ParseFloat("3.14", 10, 512, big.ToNearestEven)
+	if want := basetypes.NewNumberValue(f); !resp.Result.Value().Equal(want) {
+		t.Errorf("wrong result:\nwant: %v\ngot : %v", want, resp.Result.Value())
+	}
+}
+
+func TestNullReturns(t *testing.T) {
+	funcs := parseLibrary(t, `
+/**
+ * Returns nothing.
+ * @returns {string[]} Nothing.
+ */
+$(function nothing() {
+  return null;
+})
+`)
+
+	resp := &tffunc.RunResponse{Result: tffunc.NewResultData(basetypes.NewListUnknown(basetypes.StringType{}))}
+	runtime.TerraformFunction{Function: funcs["nothing"]}.Run(context.Background(), tffunc.RunRequest{
+		Arguments: tffunc.NewArgumentsData([]attr.Value{}),
+	}, resp)
+
+	if resp.Error != nil {
+		t.Fatalf("function was not expected to fail: %v", resp.Error)
+	}
+
+	if want := basetypes.NewListNull(basetypes.StringType{}); !resp.Result.Value().Equal(want) {
+		t.Errorf("wrong result:\nwant: %v\ngot : %v", want, resp.Result.Value())
+	}
+}
+
+func TestRegistrationAttributePolicies(t *testing.T) {
+	funcs := parseLibrary(t, `
+/**
+ * @returns {{name: string; age: number;}} The person.
+ */
+function person() {
+  return { name: "John", debug: true };
+}
+
+$(person, { name: "strict" })
+$(person, { name: "lenient", extraAttributes: "drop", missingAttributes: "null" })
+`)
+
+	run := func(name string) *tffunc.RunResponse {
+		resp := &tffunc.RunResponse{Result: tffunc.NewResultData(basetypes.NewObjectUnknown(map[string]attr.Type{
+			"name": basetypes.StringType{},
+			"age":  basetypes.NumberType{},
+		}))}
+
+		runtime.TerraformFunction{Function: funcs[name]}.Run(context.Background(), tffunc.RunRequest{
+			Arguments: tffunc.NewArgumentsData([]attr.Value{}),
+		}, resp)
+
+		return resp
+	}
+
+	if resp := run("strict"); resp.Error == nil {
+		t.Errorf("strict function was expected to fail")
+	}
+
+	resp := run("lenient")
+	if resp.Error != nil {
+		t.Fatalf("lenient function was not expected to fail: %v", resp.Error)
+	}
+
+	want := basetypes.NewObjectValueMust(
+		map[string]attr.Type{"name": basetypes.StringType{}, "age": basetypes.NumberType{}},
+		map[string]attr.Value{"name": basetypes.NewStringValue("John"), "age": basetypes.NewNumberNull()},
+	)
+
+	if !resp.Result.Value().Equal(want) {
+		t.Errorf("wrong result:\nwant: %v\ngot : %v", want, resp.Result.Value())
+	}
+}
+
+func TestLosslessNumbers(t *testing.T) {
+	funcs := parseLibrary(t, `
+$(function next(n) {
+  return n + 1n;
+})
+
+$(function concat(n) {
+  return n + "0";
+}, { numbers: "string" })
+`)
+
+	id, _, _ := big.ParseFloat("123456789012345678901", 10, 512, big.ToNearestEven)
+
+	res, err := funcs["next"].Execute(basetypes.NewNumberValue(id))
+	if err != nil {
+		t.Fatalf("function was not expected to fail: %v", err)
+	}
+
+	want, _, _ := big.ParseFloat("123456789012345678902", 10, 512, big.ToNearestEven)
+	if !res.(attr.Value).Equal(basetypes.NewNumberValue(want)) { //nolint:forcetypeassert
+		t.Errorf("wrong result: want %v, got %v", want, res)
+	}
+
+	res, err = funcs["concat"].Execute(basetypes.NewNumberValue(id))
+	if err != nil {
+		t.Fatalf("function was not expected to fail: %v", err)
+	}
+
+	if !res.(attr.Value).Equal(basetypes.NewStringValue("1234567890123456789010")) { //nolint:forcetypeassert
+		t.Errorf("wrong result: %v", res)
+	}
+}
+
+func TestNativeCollections(t *testing.T) {
+	funcs := parseLibrary(t, `
+/**
+ * @param {Map<string>} m
+ * @param {Set<number>} s
+ * @returns {{map: boolean; set: boolean;}}
+ */
+$(function kinds(m, s) {
+  return { map: m instanceof Map, set: s instanceof Set };
+}, { collections: "native" })
+
+/**
+ * @param {Map<string>} m
+ * @returns {Map<string>}
+ */
+$(function upper(m) {
+  return new Map([...m].map(([k, v]) => [k, v.toUpperCase()]));
+}, { collections: "native" })
+`)
+
+	m := basetypes.NewMapValueMust(basetypes.StringType{}, map[string]attr.Value{
+		"a": basetypes.NewStringValue("x"),
+	})
+	s := basetypes.NewSetValueMust(basetypes.NumberType{}, []attr.Value{
+		basetypes.NewNumberValue(big.NewFloat(1)),
+	})
+
+	res, err := funcs["kinds"].Execute(m, s)
+	if err != nil {
+		t.Fatalf("function was not expected to fail: %v", err)
+	}
+
+	want := basetypes.NewObjectValueMust(
+		map[string]attr.Type{"map": basetypes.BoolType{}, "set": basetypes.BoolType{}},
+		map[string]attr.Value{"map": basetypes.NewBoolValue(true), "set": basetypes.NewBoolValue(true)},
+	)
+	if !res.(attr.Value).Equal(want) { //nolint:forcetypeassert
+		t.Errorf("wrong result: want %v, got %v", want, res)
+	}
+
+	res, err = funcs["upper"].Execute(m)
+	if err != nil {
+		t.Fatalf("function was not expected to fail: %v", err)
+	}
+
+	wantMap := basetypes.NewMapValueMust(basetypes.StringType{}, map[string]attr.Value{
+		"a": basetypes.NewStringValue("X"),
+	})
+	if !res.(attr.Value).Equal(wantMap) { //nolint:forcetypeassert
+		t.Errorf("wrong result: want %v, got %v", wantMap, res)
+	}
+}
+
+func TestBinaryData(t *testing.T) {
+	funcs := parseLibrary(t, `
+/**
+ * @param {Uint8Array} data
+ * @returns {Uint8Array}
+ */
+function reverse(data) {
+  return data.slice().reverse();
+}
+
+/**
+ * @param {Uint8Array} data
+ * @returns {number}
+ */
+function size(data) {
+  return data.byteLength;
+}
+
+/**
+ * @returns {ArrayBuffer}
+ */
+function buffer() {
+  return new Uint8Array([104, 105]).buffer;
+}
+
+$(reverse)
+$(size)
+$(buffer)
+`)
+
+	res, err := funcs["reverse"].Execute(basetypes.NewStringValue("AQID"))
+	if err != nil {
+		t.Fatalf("function was not expected to fail: %v", err)
+	}
+
+	if !res.(attr.Value).Equal(basetypes.NewStringValue("AwIB")) { //nolint:forcetypeassert
+		t.Errorf("wrong result: %v", res)
+	}
+
+	res, err = funcs["size"].Execute(basetypes.NewStringValue("aGVsbG8="))
+	if err != nil {
+		t.Fatalf("function was not expected to fail: %v", err)
+	}
+
+	if !res.(attr.Value).Equal(basetypes.NewNumberValue(big.NewFloat(5))) { //nolint:forcetypeassert
+		t.Errorf("wrong result: %v", res)
+	}
+
+	res, err = funcs["buffer"].Execute()
+	if err != nil {
+		t.Fatalf("function was not expected to fail: %v", err)
+	}
+
+	if !res.(attr.Value).Equal(basetypes.NewStringValue("aGk=")) { //nolint:forcetypeassert
+		t.Errorf("wrong result: %v", res)
+	}
+
+	if _, err := funcs["size"].Execute(basetypes.NewStringValue("not base64!")); err == nil {
+		t.Errorf("function was expected to fail for invalid base64")
+	}
+}
+
+func TestDates(t *testing.T) {
+	funcs := parseLibrary(t, `
+/**
+ * @param {Date} d
+ * @returns {Date}
+ */
+function tomorrow(d) {
+  return new Date(d.getTime() + 24 * 60 * 60 * 1000);
+}
+
+/**
+ * @param {Date} d
+ * @returns {Date}
+ */
+function same(d) {
+  return d;
+}
+
+$(tomorrow)
+$(same, { dates: "milliseconds" })
+`)
+
+	res, err := funcs["tomorrow"].Execute(basetypes.NewStringValue("2024-02-28T23:30:00+01:00"))
+	if err != nil {
+		t.Fatalf("function was not expected to fail: %v", err)
+	}
+
+	if !res.(attr.Value).Equal(basetypes.NewStringValue("2024-02-29T22:30:00Z")) { //nolint:forcetypeassert
+		t.Errorf("wrong result: %v", res)
+	}
+
+	res, err = funcs["same"].Execute(basetypes.NewStringValue("2024-02-29T12:00:00.5Z"))
+	if err != nil {
+		t.Fatalf("function was not expected to fail: %v", err)
+	}
+
+	if !res.(attr.Value).Equal(basetypes.NewStringValue("2024-02-29T12:00:00.500Z")) { //nolint:forcetypeassert
+		t.Errorf("wrong result: %v", res)
+	}
+
+	if _, err := funcs["same"].Execute(basetypes.NewStringValue("yesterday")); err == nil {
+		t.Errorf("function was expected to fail for an invalid timestamp")
+	}
+
+	params, _ := funcs["same"].TerraformParameters()
+	if sp, ok := params[0].(*tffunc.StringParameter); !ok || len(sp.Validators) != 1 {
+		t.Errorf("date parameters were expected to validate timestamps, got %#v", params[0])
+	}
+}
@@ -1,10 +1,13 @@
 package javascript
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
+	"terraform-provider-func/tftypes/tfgoja"
 
+	"github.com/dop251/goja"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 )
@@ -34,6 +37,12 @@ func getTerraformType(tys string) (attr.Type, error) {
 		return &basetypes.StringType{}, nil
 	case "any", "":
 		return &basetypes.DynamicType{}, nil
+	case "Uint8Array", "ArrayBuffer":
+		// Binary data is passed as a base64 encoded string
+		return &basetypes.StringType{}, nil
+	case "Date":
+		// Dates are passed as RFC 3339 timestamps
+		return &basetypes.StringType{}, nil
 	default:
 		break
 	}
@@ -167,3 +176,19 @@ func getTerraformType(tys string) (attr.Type, error) {
 
 	return &basetypes.DynamicType{}, nil
 }
+
+// argumentDecoder converts a Terraform argument into a JavaScript value.
+type argumentDecoder func(ctx context.Context, v attr.Value, js *goja.Runtime) (goja.Value, error)
+
+// getArgumentDecoder returns the decoder of the JavaScript (TypeScript) types
+// that Terraform represents as strings, or nil for any other type.
+func getArgumentDecoder(tys string) argumentDecoder {
+	switch tys {
+	case "Uint8Array", "ArrayBuffer":
+		return tfgoja.BytesFromTfValue
+	case "Date":
+		return tfgoja.DateFromTfValue
+	}
+
+	return nil
+}
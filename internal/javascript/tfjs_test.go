@@ -20,6 +20,10 @@ func TestGetTerraformType(t *testing.T) {
 		{"String type", "string", basetypes.StringType{}, false},
 		{"Any type", "any", basetypes.DynamicType{}, false},
 
+		// Binary data
+		{"Uint8Array type", "Uint8Array", basetypes.StringType{}, false},
+		{"ArrayBuffer type", "ArrayBuffer", basetypes.StringType{}, false},
+
 		// Arrays
 		{"Array of booleans", "boolean[]", basetypes.ListType{ElemType: basetypes.BoolType{}}, false},
 		{"Array of numbers", "number[]", basetypes.ListType{ElemType: basetypes.NumberType{}}, false},
@@ -2,10 +2,12 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"terraform-provider-func/internal/runtime"
 	"terraform-provider-func/tftypes"
+	"terraform-provider-func/tftypes/tfarg"
 	"terraform-provider-func/tftypes/tfconvert"
 
 	"github.com/hashicorp/terraform-plugin-framework/attr"
@@ -105,8 +107,8 @@ func (d *DataSource) Read(ctx context.Context, req datasource.ReadRequest, resp
 		return
 	}
 
-	// If any of the inputs are unknown, we need to defer the execution
-	if data.Id.IsUnknown() || data.Inputs.IsUnknown() || data.Inputs.IsUnderlyingValueUnknown() {
+	// If the function name is unknown, we need to defer the execution
+	if data.Id.IsUnknown() {
 		data.Result = basetypes.NewDynamicUnknown()
 		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 		return
@@ -124,6 +126,21 @@ func (d *DataSource) Read(ctx context.Context, req datasource.ReadRequest, resp
 		return
 	}
 
+	if msg := fn.DeprecationMessage(); msg != "" {
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root("id"),
+			"Function deprecated.",
+			fmt.Sprintf("Function '%s' is deprecated: %s", fnName, msg),
+		)
+	}
+
+	// If any of the inputs are unknown, we need to defer the execution
+	if data.Inputs.IsUnknown() || data.Inputs.IsUnderlyingValueUnknown() {
+		data.Result = basetypes.NewDynamicUnknown()
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		return
+	}
+
 	params, err := fn.TerraformParameters()
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -169,43 +186,58 @@ func (d *DataSource) Read(ctx context.Context, req datasource.ReadRequest, resp
 				return
 			}
 
-			if !params[pos].GetType().Equal(v.Type(ctx)) {
+			pty := tfarg.ParameterType(params[pos])
+			arg, err := coerceInput(ctx, pty, v)
+			if err != nil {
 				resp.Diagnostics.AddAttributeError(
 					path.Root("inputs").AtMapKey(k),
 					"Parameter type mismatch.",
 					fmt.Sprintf(
-						"Parameter '%s' of function '%s' has type '%v', but received a value of type '%v'.",
+						"Parameter '%s' of function '%s' has type '%v', but received a value of type '%v' that cannot be converted: %s.",
 						k,
 						fnName,
-						params[pos].GetType().String(),
+						pty.String(),
 						v.Type(ctx).String(),
+						describeConversionError(err),
 					),
 				)
 				return
 			}
 
-			args[pos] = v
+			args[pos] = arg
 		}
 	} else if tftypes.IsTupleType(valTy) {
 		tuple := tftypes.EnsurePointer(val).(*basetypes.TupleValue) //nolint:forcetypeassert
 
+		if len(tuple.Elements()) > len(params) {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("inputs").AtTupleIndex(len(params)),
+				"Too many parameters.",
+				fmt.Sprintf("Function '%s' has %d parameters, but received %d values.", fnName, len(params), len(tuple.Elements())),
+			)
+			return
+		}
+
 		for i, v := range tuple.Elements() {
-			if !params[i].GetType().Equal(v.Type(ctx)) {
+			pty := tfarg.ParameterType(params[i])
+			arg, err := coerceInput(ctx, pty, v)
+			if err != nil {
 				resp.Diagnostics.AddAttributeError(
 					path.Root("inputs").AtTupleIndex(i),
 					"Parameter type mismatch.",
 					fmt.Sprintf(
-						"Parameter #%d of function '%s' has type '%v', but received a value of type '%v'.",
+						"Parameter #%d of function '%s' has type '%v', but received a value of type '%v' that cannot be converted: %s.",
 						i,
 						fnName,
-						params[i].GetType().String(),
+						pty.String(),
 						v.Type(ctx).String(),
+						describeConversionError(err),
 					),
 				)
 				return
 			}
 
-			args[i] = v
+			args[i] = arg
 		}
 
 	} else {
@@ -246,17 +278,18 @@ func (d *DataSource) Read(ctx context.Context, req datasource.ReadRequest, resp
 	}
 
 	resVal := res.(attr.Value) //nolint:forcetypeassert
-	if !ret.GetType().Equal(resVal.Type(ctx)) {
-		convertedVal, err := tfconvert.Convert(ctx, resVal, ret.GetType())
+	if rty := tfarg.ReturnType(ret); !tftypes.TypeEqual(rty, resVal.Type(ctx)) {
+		convertedVal, err := tfconvert.ConvertWithOptions(ctx, resVal, rty, fn.ConversionOptions())
 		if err != nil {
 			resp.Diagnostics.AddAttributeError(
 				path.Root("result"),
 				"Return type mismatch.",
 				fmt.Sprintf(
-					"Return of function '%s' has type '%v', but received a value of type '%v' (and the provider failed to convert it).",
+					"Return of function '%s' has type '%v', but received a value of type '%v' that cannot be converted: %s.",
 					fnName,
-					ret.GetType().String(),
+					rty.String(),
 					resVal.Type(ctx).String(),
+					describeConversionError(err),
 				),
 			)
 			return
@@ -271,6 +304,38 @@ func (d *DataSource) Read(ctx context.Context, req datasource.ReadRequest, resp
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
+// coerceInput converts an input value into the type of the parameter
+// that receives it, the same way Terraform converts function arguments.
+//
+// Values that are assignable to the parameter (e.g. a tuple literal to a list)
+// only have their structure converted. Other values also have their primitives
+// converted when possible (e.g. a number to a string), and the attributes of
+// objects that the parameter does not declare are discarded.
+func coerceInput(ctx context.Context, param attr.Type, value attr.Value) (attr.Value, error) {
+	vty := value.Type(ctx)
+
+	if tftypes.IsDynamicType(param) || tftypes.TypeEqual(vty, param) {
+		return value, nil
+	}
+
+	if tftypes.TypeAssignable(vty, param) {
+		return tfconvert.Convert(ctx, value, param)
+	}
+
+	return tfconvert.ConvertWithOptions(ctx, value, param, tfconvert.Options{DropExtraAttributes: true})
+}
+
+// describeConversionError describes why a value could not be converted,
+// pointing to the element that failed the conversion if any.
+func describeConversionError(err error) string {
+	var cerr *tfconvert.Error
+	if errors.As(err, &cerr) && len(cerr.Path) > 0 {
+		return fmt.Sprintf("the conversion failed at %s (%v)", cerr.PathString(), cerr.Err)
+	}
+
+	return fmt.Sprintf("the conversion failed (%v)", err)
+}
+
 type InputsValidator struct{}
 
 func (v *InputsValidator) Description(_ context.Context) string {
@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+func TestCoerceInput(t *testing.T) {
+	ctx := context.Background()
+
+	tests := map[string]struct {
+		param attr.Type
+		value attr.Value
+		want  attr.Value
+		err   bool
+	}{
+		"Tuple to list": {
+			param: basetypes.ListType{ElemType: basetypes.NumberType{}},
+			value: basetypes.NewTupleValueMust(
+				[]attr.Type{basetypes.NumberType{}},
+				[]attr.Value{basetypes.NewNumberValue(big.NewFloat(1))},
+			),
+			want: basetypes.NewListValueMust(
+				basetypes.NumberType{},
+				[]attr.Value{basetypes.NewNumberValue(big.NewFloat(1))},
+			),
+		},
+		"Number to string": {
+			param: basetypes.StringType{},
+			value: basetypes.NewNumberValue(big.NewFloat(1)),
+			want:  basetypes.NewStringValue("1"),
+		},
+		"Extra attributes": {
+			param: basetypes.ObjectType{AttrTypes: map[string]attr.Type{"a": basetypes.StringType{}}},
+			value: basetypes.NewObjectValueMust(
+				map[string]attr.Type{"a": basetypes.StringType{}, "b": basetypes.BoolType{}},
+				map[string]attr.Value{"a": basetypes.NewStringValue("a"), "b": basetypes.NewBoolValue(true)},
+			),
+			want: basetypes.NewObjectValueMust(
+				map[string]attr.Type{"a": basetypes.StringType{}},
+				map[string]attr.Value{"a": basetypes.NewStringValue("a")},
+			),
+		},
+		"Missing attributes": {
+			param: basetypes.ObjectType{AttrTypes: map[string]attr.Type{"a": basetypes.StringType{}, "b": basetypes.BoolType{}}},
+			value: basetypes.NewObjectValueMust(
+				map[string]attr.Type{"a": basetypes.StringType{}},
+				map[string]attr.Value{"a": basetypes.NewStringValue("a")},
+			),
+			err: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := coerceInput(ctx, test.param, test.value)
+			if test.err {
+				if err == nil {
+					t.Errorf("conversion was expected to fail, got %v", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("conversion was not expected to fail: %v", err)
+			}
+
+			if !got.Equal(test.want) {
+				t.Errorf("wrong result:\nwant: %v\ngot : %v", test.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"terraform-provider-func/internal/runtime"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// conflictPolicy is how functions defined by several libraries are handled.
+type conflictPolicy string
+
+const (
+	// conflictError refuses to configure the provider.
+	conflictError conflictPolicy = "error"
+
+	// conflictWarn keeps the function of the last loaded library, with a warning.
+	conflictWarn conflictPolicy = "warn"
+
+	// conflictLastWins silently keeps the function of the last loaded library.
+	conflictLastWins conflictPolicy = "last-wins"
+
+	defaultConflictPolicy = conflictWarn
+)
+
+// parseConflictPolicy parses a conflict policy, which defaults to warn.
+func parseConflictPolicy(s string) (conflictPolicy, error) {
+	switch p := conflictPolicy(s); p {
+	case "":
+		return defaultConflictPolicy, nil
+	case conflictError, conflictWarn, conflictLastWins:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown conflict policy '%s' (use %s, %s or %s)", s, conflictError, conflictWarn, conflictLastWins)
+	}
+}
+
+// loadConflictPolicy reads the conflict policy from the `FUNC_CONFLICT_POLICY`
+// environment variable.
+func loadConflictPolicy() (conflictPolicy, error) {
+	return parseConflictPolicy(os.Getenv("FUNC_CONFLICT_POLICY"))
+}
+
+// functionConflict is a function defined by two libraries.
+type functionConflict struct {
+	// name is the name of the function.
+	name string
+
+	// previous is where the overridden function was defined.
+	previous string
+
+	// current is where the function that is kept was defined.
+	current string
+}
+
+func (c functionConflict) String() string {
+	return fmt.Sprintf("The function '%s' is defined by both %s and %s.", c.name, c.previous, c.current)
+}
+
+// functionIndex holds the functions of all the runtimes by name, along with
+// the library each of them was defined by.
+//
+// Libraries are indexed in the order they are loaded, so that when several
+// of them define the same function (even in different runtimes), the last
+// one wins.
+type functionIndex struct {
+	funcs   map[string]runtime.Function
+	origins map[string]string
+}
+
+func newFunctionIndex() *functionIndex {
+	return &functionIndex{
+		funcs:   make(map[string]runtime.Function),
+		origins: make(map[string]string),
+	}
+}
+
+// snapshot returns the functions of a runtime before it parses a library,
+// so that the functions defined by the library can be told apart.
+func snapshot(vm runtime.Runtime) map[string]runtime.Function {
+	funcs := make(map[string]runtime.Function)
+	for _, f := range vm.Functions() {
+		funcs[f.Name()] = f
+	}
+
+	return funcs
+}
+
+// update indexes the functions defined by a library, given the functions of
+// its runtime before the library was parsed. It returns the conflicts the
+// library caused.
+func (idx *functionIndex) update(vm runtime.Runtime, before map[string]runtime.Function, origin string) []functionConflict {
+	funcs := vm.Functions()
+	sort.Slice(funcs, func(i, j int) bool {
+		return funcs[i].Name() < funcs[j].Name()
+	})
+
+	var conflicts []functionConflict
+	for _, f := range funcs {
+		name := f.Name()
+
+		// The function is left untouched by the library
+		if prev, ok := before[name]; ok && prev == f {
+			continue
+		}
+
+		if previous, ok := idx.origins[name]; ok && previous != origin {
+			conflicts = append(conflicts, functionConflict{name: name, previous: previous, current: origin})
+		}
+
+		idx.funcs[name] = f
+		idx.origins[name] = origin
+	}
+
+	return conflicts
+}
+
+// functions returns the indexed functions, sorted by name.
+func (idx *functionIndex) functions() []runtime.Function {
+	names := make([]string, 0, len(idx.funcs))
+	for name := range idx.funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	funcs := make([]runtime.Function, 0, len(names))
+	for _, name := range names {
+		funcs = append(funcs, idx.funcs[name])
+	}
+
+	return funcs
+}
+
+// reportConflicts adds the given conflicts to the diagnostics, according
+// to the policy.
+func reportConflicts(policy conflictPolicy, conflicts []functionConflict, diags *diag.Diagnostics) {
+	for _, c := range conflicts {
+		switch policy {
+		case conflictError:
+			diags.AddError(
+				"Conflicting function.",
+				fmt.Sprintf("%s Rename one of them, or set the conflict policy to 'warn' or 'last-wins'.", c),
+			)
+		case conflictWarn:
+			diags.AddWarning(
+				"Conflicting function.",
+				fmt.Sprintf("%s The function of the last one is used.", c),
+			)
+		}
+	}
+}
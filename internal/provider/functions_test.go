@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"testing"
+
+	"terraform-provider-func/internal/javascript"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestFunctionIndex(t *testing.T) {
+	index := newFunctionIndex()
+	vm := javascript.New()
+
+	parse := func(origin string, src string) []functionConflict {
+		t.Helper()
+
+		before := snapshot(vm)
+		if ds := vm.Parse(origin, src); ds.HasError() {
+			t.Fatalf("library %s was not expected to fail: %v", origin, ds)
+		}
+
+		return index.update(vm, before, origin)
+	}
+
+	if conflicts := parse("a", `$(function shared() { return "a"; }); $(function first() { return 1; })`); len(conflicts) != 0 {
+		t.Fatalf("no conflict was expected, got %v", conflicts)
+	}
+
+	if conflicts := parse("b", `$(function other() { return 2; })`); len(conflicts) != 0 {
+		t.Fatalf("functions left untouched were not expected to conflict, got %v", conflicts)
+	}
+
+	conflicts := parse("c", `$(function shared() { return "c"; })`)
+	if len(conflicts) != 1 || conflicts[0] != (functionConflict{name: "shared", previous: "a", current: "c"}) {
+		t.Fatalf("a conflict between a and c was expected, got %v", conflicts)
+	}
+
+	names := []string{}
+	for _, f := range index.functions() {
+		names = append(names, f.Name())
+	}
+
+	if len(names) != 3 || names[0] != "first" || names[1] != "other" || names[2] != "shared" {
+		t.Errorf("functions were expected to be sorted by name, got %v", names)
+	}
+
+	// The last loaded library wins
+	result, err := index.funcs["shared"].Execute()
+	if err != nil || result != types.StringValue("c") {
+		t.Errorf("the function of c was expected, got %v (%v)", result, err)
+	}
+
+	t.Run("Runtimes", func(t *testing.T) {
+		other := javascript.New()
+
+		before := snapshot(other)
+		other.Parse("d", `$(function first() { return 4; })`)
+
+		if conflicts := index.update(other, before, "d"); len(conflicts) != 1 || conflicts[0].previous != "a" {
+			t.Errorf("functions of different runtimes were expected to conflict, got %v", conflicts)
+		}
+	})
+
+	t.Run("Policies", func(t *testing.T) {
+		tests := map[conflictPolicy]func(ds diag.Diagnostics) bool{
+			conflictError:    func(ds diag.Diagnostics) bool { return ds.ErrorsCount() == 1 },
+			conflictWarn:     func(ds diag.Diagnostics) bool { return ds.WarningsCount() == 1 && !ds.HasError() },
+			conflictLastWins: func(ds diag.Diagnostics) bool { return len(ds) == 0 },
+		}
+
+		for policy, check := range tests {
+			ds := diag.Diagnostics{}
+			reportConflicts(policy, conflicts, &ds)
+
+			if !check(ds) {
+				t.Errorf("unexpected diagnostics for the %s policy: %v", policy, ds)
+			}
+		}
+	})
+
+	t.Run("Parsing", func(t *testing.T) {
+		if p, err := parseConflictPolicy(""); err != nil || p != conflictWarn {
+			t.Errorf("the policy was expected to default to warn, got %s (%v)", p, err)
+		}
+
+		if _, err := parseConflictPolicy("first-wins"); err == nil {
+			t.Errorf("an unknown policy was expected to fail")
+		}
+	})
+}
@@ -2,19 +2,37 @@ package provider
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"terraform-provider-func/internal/getter"
+	"time"
 
+	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 const (
-	variablePrefix       string = "FUNC_LIBRARY_"
-	sourceVariableSuffix string = "_SOURCE"
+	variablePrefix          string = "FUNC_LIBRARY_"
+	sourceVariableSuffix    string = "_SOURCE"
+	checksumVariableSuffix  string = "_CHECKSUM"
+	signatureVariableSuffix string = "_SIGNATURE"
+	cacheTTLVariableSuffix  string = "_CACHE_TTL"
+	priorityVariableSuffix  string = "_PRIORITY"
+	mirrorVariablePrefix    string = "FUNC_MIRROR_"
+	prefixVariableSuffix    string = "_PREFIX"
+	pathVariableSuffix      string = "_PATH"
+	defaultLockFile         string = ".func.lock.json"
+	defaultLanguage         string = "js"
 )
 
 // getDefaultCacheFolderPath returns the default cache directory path
@@ -38,16 +56,362 @@ func getDefaultCacheFolderPath() (string, error) {
 	return cacheDir, nil
 }
 
+// fetchSettings describes how the libraries are fetched and cached.
+type fetchSettings struct {
+	// path is the cache directory.
+	path string
+
+	// maxSize is the maximum size of the cache in bytes (zero means unbounded).
+	maxSize int64
+
+	// refresh forces every library to be downloaded again.
+	refresh bool
+
+	// offline prevents any library from being downloaded from the network.
+	offline bool
+
+	// mirrors are the local directories consulted before downloading a library.
+	mirrors []getter.Mirror
+}
+
+// loadFetchSettings reads the fetch settings from the environment.
+//
+// Libraries are downloaded again if the `FUNC_CACHE_REFRESH` environment
+// variable is set to true, and only resolved from the cache if `FUNC_OFFLINE`
+// is set to true. The size of the cache is bounded by the `FUNC_CACHE_MAX_SIZE`
+// environment variable.
+//
+// Mirrors are given by pairs of `FUNC_MIRROR_{ID}_PREFIX` and
+// `FUNC_MIRROR_{ID}_PATH` environment variables.
+func loadFetchSettings(settings *fetchSettings) error {
+	if v, ok := os.LookupEnv("FUNC_CACHE_REFRESH"); ok {
+		refresh, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("FUNC_CACHE_REFRESH must be a boolean, got '%s'", v)
+		}
+
+		settings.refresh = refresh
+	}
+
+	if v, ok := os.LookupEnv("FUNC_OFFLINE"); ok {
+		offline, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("FUNC_OFFLINE must be a boolean, got '%s'", v)
+		}
+
+		settings.offline = offline
+	}
+
+	if v, ok := os.LookupEnv("FUNC_CACHE_MAX_SIZE"); ok {
+		maxSize, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || maxSize < 0 {
+			return fmt.Errorf("FUNC_CACHE_MAX_SIZE must be a positive number of bytes, got '%s'", v)
+		}
+
+		settings.maxSize = maxSize
+	}
+
+	for _, v := range os.Environ() {
+		name, prefix, _ := strings.Cut(v, "=")
+		if !strings.HasPrefix(name, mirrorVariablePrefix) || !strings.HasSuffix(name, prefixVariableSuffix) {
+			continue
+		}
+
+		id := strings.TrimSuffix(strings.TrimPrefix(name, mirrorVariablePrefix), prefixVariableSuffix)
+		dir, ok := os.LookupEnv(mirrorVariablePrefix + id + pathVariableSuffix)
+		if !ok {
+			return fmt.Errorf("the mirror %s has no %s variable", name, mirrorVariablePrefix+id+pathVariableSuffix)
+		}
+
+		settings.mirrors = append(settings.mirrors, getter.Mirror{Prefix: prefix, Path: dir})
+	}
+
+	return nil
+}
+
+// input returns what is needed to fetch the given source.
+func (s *fetchSettings) input(source string, checksum string, ttl time.Duration) *getter.FetchInput {
+	return &getter.FetchInput{
+		URL:      source,
+		Checksum: checksum,
+		Path:     s.path,
+		TTL:      ttl,
+		Refresh:  s.refresh,
+		Offline:  s.offline,
+		Mirrors:  s.mirrors,
+	}
+}
+
+// parseCacheTTL parses how long a library is cached, as a duration
+// (e.g. `1h30m`). An empty duration means the library is cached forever.
+func parseCacheTTL(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	ttl, err := time.ParseDuration(s)
+	if err != nil || ttl < 0 {
+		return 0, fmt.Errorf("the cache TTL must be a positive duration (e.g. '1h30m'), got '%s'", s)
+	}
+
+	return ttl, nil
+}
+
+// loadLock reads the lock file found at the given path.
+//
+// The lock is in upgrade mode if the `FUNC_LOCK_UPGRADE` environment
+// variable is set to true.
+func loadLock(path string) (*getter.Lock, error) {
+	lock, err := getter.LoadLock(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if v, ok := os.LookupEnv("FUNC_LOCK_UPGRADE"); ok {
+		upgrade, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("FUNC_LOCK_UPGRADE must be a boolean, got '%s'", v)
+		}
+
+		lock.Upgrade = upgrade
+	}
+
+	return lock, nil
+}
+
+// loadKeyring parses the public keys that are trusted to sign libraries.
+//
+// Without keys, there is no keyring (and libraries are not verified).
+func loadKeyring(keys []string) (*getter.Keyring, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	return getter.ParseKeyring(keys...)
+}
+
+// verifyLibrary checks that the library was signed by one of the trusted
+// keys, using the detached signature found at the given source.
+//
+// Without a keyring, libraries are not verified, so a signature is refused
+// rather than being silently ignored.
+func verifyLibrary(ctx context.Context, debug debugLogger, keyring *getter.Keyring, libs []Library, signature string, settings *fetchSettings, ttl time.Duration) error {
+	if keyring == nil {
+		if signature != "" {
+			return fmt.Errorf("%w: the library is signed, but there is no trusted key to verify it", getter.ErrInvalidSignature)
+		}
+
+		return nil
+	}
+
+	if signature == "" {
+		return fmt.Errorf("%w: the library is not signed, but only signed libraries are trusted", getter.ErrInvalidSignature)
+	}
+
+	// A detached signature signs a single file
+	if len(libs) != 1 {
+		return fmt.Errorf("%w: only a library of a single file can be signed, got %d files", getter.ErrInvalidSignature, len(libs))
+	}
+
+	content, err := libs[0].read()
+	if err != nil {
+		return err
+	}
+
+	sigPath, err := getter.Fetch(ctx, settings.input(signature, "", ttl))
+	if err != nil {
+		return fmt.Errorf("could not download signature: %w", err)
+	}
+
+	signer, err := keyring.VerifyContent([]byte(content), sigPath)
+	if err != nil {
+		return err
+	}
+
+	debug("library signature verified", map[string]any{
+		"library": libs[0].name(),
+		"signer":  signer,
+	})
+
+	return nil
+}
+
+// debugLogger logs a debug message along with its fields.
+//
+// The libraries found in the environment are loaded before the provider is
+// served, when tflog cannot be used yet, so they are logged with the hclog
+// logger of the provider instead.
+type debugLogger func(msg string, fields map[string]any)
+
+// tflogDebug returns a debugLogger logging with tflog.
+func tflogDebug(ctx context.Context) debugLogger {
+	return func(msg string, fields map[string]any) {
+		tflog.Debug(ctx, msg, fields)
+	}
+}
+
+// hclogDebug returns a debugLogger logging with the given hclog logger.
+func hclogDebug(logger hclog.Logger) debugLogger {
+	return func(msg string, fields map[string]any) {
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		args := make([]any, 0, len(fields)*2)
+		for _, k := range keys {
+			args = append(args, k, fields[k])
+		}
+
+		logger.Debug(msg, args...)
+	}
+}
+
+// fetchLibraries fetches the sources of the given inputs in parallel, and
+// returns their results in the same order.
+//
+// The fetches share a context canceled on interrupt, so that they all stop
+// at once rather than each handling the interrupt on its own.
+func fetchLibraries(ctx context.Context, debug debugLogger, lock *getter.Lock, inputs []*getter.FetchInput) []getter.FetchResult {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	fetcher := &getter.Fetcher{Fetch: lock.FetchAll}
+	results := fetcher.FetchAll(ctx, inputs)
+
+	for i, r := range results {
+		debug("library fetched", map[string]any{
+			"source":   inputs[i].URL,
+			"duration": r.Duration.String(),
+			"attempts": r.Attempts,
+			"success":  r.Err == nil,
+		})
+	}
+
+	return results
+}
+
+// evictLibraries bounds the size of the cache once the libraries of a run
+// are fetched, keeping all of them so that none is removed before it is read.
+func evictLibraries(settings *fetchSettings, results []getter.FetchResult) error {
+	var keep []getter.File
+	for _, r := range results {
+		keep = append(keep, r.Files...)
+	}
+
+	return getter.EvictCache(settings.path, settings.maxSize, keep)
+}
+
+// Library is a library found in the configuration or in the environment.
+type Library struct {
+	// Path is the local copy of the library, unless it is written inline.
+	Path string
+
+	// Content is the content of a library written inline.
+	Content string
+
+	// Language is the language of a library written inline.
+	Language string
+
+	// Source is the source the library was found in (or where it was
+	// written, if it is inline).
+	Source string
+
+	// Location is where the library is configured (e.g. `library[0]` or
+	// `FUNC_LIBRARY_0001_SOURCE`).
+	Location string
+
+	// Expanded is set when the source holds several files (a directory, an
+	// archive or a glob), in which case the files that no runtime can parse
+	// are skipped.
+	Expanded bool
+}
+
+// key identifies the library, so that it is parsed only once.
+func (l *Library) key() string {
+	if l.Path != "" {
+		return l.Path
+	}
+
+	// Inline libraries are identified by their content, since they have no path
+	sum := sha256.Sum256([]byte(l.Language + "\x00" + l.Content))
+
+	return "inline:" + hex.EncodeToString(sum[:])
+}
+
+// name returns how the library is referred to in diagnostics.
+func (l *Library) name() string {
+	if l.Path != "" {
+		return l.Path
+	}
+
+	return l.Source
+}
+
+// origin describes where the library comes from, so that both libraries
+// can be told apart when they define the same function.
+func (l *Library) origin() string {
+	if l.Path == "" {
+		return fmt.Sprintf("%s (written inline)", l.Location)
+	}
+
+	return fmt.Sprintf("%s (source '%s', file '%s')", l.Location, l.Source, l.Path)
+}
+
+// runtime returns the key of the runtime that parses the library: its
+// language if it is inline, or the extension of its file.
+func (l *Library) runtime() string {
+	if l.Path == "" {
+		return l.Language
+	}
+
+	return strings.TrimPrefix(filepath.Ext(l.Path), ".")
+}
+
+// read returns the content of the library.
+func (l *Library) read() (string, error) {
+	if l.Path == "" {
+		return l.Content, nil
+	}
+
+	content, err := os.ReadFile(l.Path)
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
+
+// librariesOf returns the libraries of the files fetched from a source,
+// configured at the given location.
+func librariesOf(location string, source string, files []getter.File) []Library {
+	libs := make([]Library, 0, len(files))
+	for _, f := range files {
+		libs = append(libs, Library{
+			Path:     f.Path,
+			Source:   source,
+			Location: location,
+			Expanded: len(files) > 1,
+		})
+	}
+
+	return libs
+}
+
 // FindLibrariesInEnvironment prepares libraries found in environment
 // for parsing.
 //
-// Any found library will be downloaded and the path to the local copy
-// will be returned.
+// Any found library will be downloaded and its local copy will be
+// returned. A source can hold several libraries.
+//
+// Libraries are returned in the order they must be loaded: by ascending
+// priority (`FUNC_LIBRARY_{ID}_PRIORITY`, zero by default), then by ID.
 //
 // If optimistic is set, the search will not be canceled because some
 // library cannot be processed.
-func FindLibrariesInEnvironment(optimistic bool) ([]string, diag.Diagnostics) {
-	ctx := context.Background()
+func FindLibrariesInEnvironment(ctx context.Context, logger hclog.Logger, optimistic bool) ([]Library, diag.Diagnostics) {
+	debug := hclogDebug(logger)
 	diags := diag.Diagnostics{}
 
 	fetchDst := ""
@@ -60,12 +424,51 @@ func FindLibrariesInEnvironment(optimistic bool) ([]string, diag.Diagnostics) {
 		fetchDst = cacheDir
 	}
 
+	settings := &fetchSettings{path: fetchDst}
+	if err := loadFetchSettings(settings); err != nil {
+		diags.AddError("Cannot load fetch settings.", err.Error())
+		return nil, diags
+	}
+
+	lockPath := defaultLockFile
+	if path, ok := os.LookupEnv("FUNC_LOCK_FILE"); ok {
+		lockPath = path
+	}
+
+	lock, err := loadLock(lockPath)
+	if err != nil {
+		diags.AddError("Cannot load lock file.", err.Error())
+		return nil, diags
+	}
+
+	var keys []string
+	if v, ok := os.LookupEnv("FUNC_TRUSTED_KEYS"); ok {
+		keys = append(keys, v)
+	}
+
+	keyring, err := loadKeyring(keys)
+	if err != nil {
+		diags.AddError("Cannot load trusted keys.", err.Error())
+		return nil, diags
+	}
+
 	var appendDiag func(summary string, detail string) = diags.AddError
 	if optimistic {
 		appendDiag = diags.AddWarning
 	}
 
-	paths := make([]string, 0)
+	// pending is a library to fetch
+	type pending struct {
+		id        string
+		variable  string
+		source    string
+		signature string
+		ttl       time.Duration
+		priority  int64
+		input     *getter.FetchInput
+	}
+
+	var found []pending
 
 	for _, v := range os.Environ() {
 		if strings.HasPrefix(v, variablePrefix) {
@@ -88,31 +491,98 @@ func FindLibrariesInEnvironment(optimistic bool) ([]string, diag.Diagnostics) {
 
 			source := parts[1] // source of the library
 
-			p, err := getter.Fetch(ctx, &getter.FetchInput{
-				URL:  source,
-				Path: fetchDst,
-			})
+			// The checksum, the signature and the cache TTL of the library are given by the variables with the same ID
+			id := strings.TrimSuffix(strings.TrimPrefix(parts[0], variablePrefix), sourceVariableSuffix)
+			checksum := os.Getenv(variablePrefix + id + checksumVariableSuffix)
+			signature := os.Getenv(variablePrefix + id + signatureVariableSuffix)
 
+			ttl, err := parseCacheTTL(os.Getenv(variablePrefix + id + cacheTTLVariableSuffix))
 			if err != nil {
-				appendDiag("Could not download library.", err.Error())
+				appendDiag("Cannot parse cache TTL.", fmt.Sprintf("The library '%s' (%s) has an invalid cache TTL: %v.", source, parts[0], err))
+				continue
 			}
 
-			paths = append(paths, p)
+			var priority int64
+			if v := os.Getenv(variablePrefix + id + priorityVariableSuffix); v != "" {
+				priority, err = strconv.ParseInt(v, 10, 64)
+				if err != nil {
+					appendDiag("Cannot parse priority.", fmt.Sprintf("The library '%s' (%s) has an invalid priority: '%s' is not an integer.", source, parts[0], v))
+					continue
+				}
+			}
+
+			found = append(found, pending{
+				id:        id,
+				variable:  parts[0],
+				source:    source,
+				signature: signature,
+				ttl:       ttl,
+				priority:  priority,
+				input:     settings.input(source, checksum, ttl),
+			})
 		}
 	}
 
-	return paths, nil
+	// The environment has no order, so the libraries are sorted
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].priority != found[j].priority {
+			return found[i].priority < found[j].priority
+		}
+
+		return found[i].id < found[j].id
+	})
+
+	inputs := make([]*getter.FetchInput, 0, len(found))
+	for _, lib := range found {
+		inputs = append(inputs, lib.input)
+	}
+
+	libraries := make([]Library, 0)
+
+	results := fetchLibraries(ctx, debug, lock, inputs)
+	if err := evictLibraries(settings, results); err != nil {
+		appendDiag("Cannot evict cached libraries.", err.Error())
+	}
+
+	for i, r := range results {
+		lib := found[i]
+
+		if r.Err != nil {
+			appendDiag("Could not download library.", r.Err.Error())
+			continue
+		}
+
+		// Untrusted libraries are never loaded, even if optimistic
+		if err := verifyLibrary(ctx, debug, keyring, librariesOf(lib.variable, lib.source, r.Files), lib.signature, settings, lib.ttl); err != nil {
+			diags.AddError(
+				"Could not verify library signature.",
+				fmt.Sprintf("The library '%s' (%s) cannot be trusted: %v.", lib.source, lib.variable, err),
+			)
+			continue
+		}
+
+		libraries = append(libraries, librariesOf(lib.variable, lib.source, r.Files)...)
+	}
+
+	if err := lock.Save(); err != nil {
+		appendDiag("Cannot save lock file.", err.Error())
+	}
+
+	return libraries, diags
 }
 
 // FindLibrariesInModel prepares libraries found in a provider model.
 //
-// Any found library will be downloaded and the path to the local copy
-// will be returned.
+// Any found library will be downloaded and its local copy will be
+// returned. A source can hold several libraries.
+//
+// Libraries are returned in the order they must be loaded: by ascending
+// priority (zero by default), then in the order of the configuration.
 //
 // If optimistic is set, the search will not be canceled because some
 // library cannot be processed.
-func FindLibrariesInModel(model *FuncProviderModel, optimistic bool) ([]string, diag.Diagnostics) {
-	ctx := context.Background()
+func FindLibrariesInModel(ctx context.Context, model *FuncProviderModel, optimistic bool) ([]Library, diag.Diagnostics) {
+	debug := tflogDebug(ctx)
 	diags := diag.Diagnostics{}
 
 	var libs []LibraryModel = []LibraryModel{}
@@ -155,19 +625,180 @@ func FindLibrariesInModel(model *FuncProviderModel, optimistic bool) ([]string,
 		fetchDst = cacheDir
 	}
 
-	paths := make([]string, 0)
+	settings := &fetchSettings{path: fetchDst}
+	if err := loadFetchSettings(settings); err != nil {
+		diags.AddError("Cannot load fetch settings.", err.Error())
+		return nil, diags
+	}
+
+	if !model.CacheMaxSize.IsNull() && !model.CacheMaxSize.IsUnknown() {
+		if model.CacheMaxSize.ValueInt64() < 0 {
+			diags.AddAttributeError(path.Root("cache_max_size"), "Invalid cache size.", "The maximum size of the cache cannot be negative.")
+			return nil, diags
+		}
+
+		settings.maxSize = model.CacheMaxSize.ValueInt64()
+	}
+
+	if !model.Offline.IsNull() && !model.Offline.IsUnknown() {
+		settings.offline = model.Offline.ValueBool()
+	}
+
+	if !model.Mirror.IsNull() && !model.Mirror.IsUnknown() {
+		var mirrors []MirrorModel
+		diags.Append(model.Mirror.ElementsAs(ctx, &mirrors, false)...)
+		if diags.HasError() {
+			return nil, diags
+		}
+
+		if len(mirrors) > 0 {
+			settings.mirrors = nil
+		}
+
+		for _, m := range mirrors {
+			settings.mirrors = append(settings.mirrors, getter.Mirror{
+				Prefix: m.Prefix.ValueString(),
+				Path:   m.Path.ValueString(),
+			})
+		}
+	}
+
+	lockPath := defaultLockFile
+	if !model.LockFile.IsNull() && !model.LockFile.IsUnknown() {
+		lockPath = model.LockFile.ValueString()
+	} else if path, ok := os.LookupEnv("FUNC_LOCK_FILE"); ok {
+		lockPath = path
+	}
+
+	lock, err := loadLock(lockPath)
+	if err != nil {
+		diags.AddAttributeError(path.Root("lock_file"), "Cannot load lock file.", err.Error())
+		return nil, diags
+	}
+
+	var keys []string
+	if !model.TrustedKeys.IsNull() && !model.TrustedKeys.IsUnknown() {
+		diags.Append(model.TrustedKeys.ElementsAs(ctx, &keys, false)...)
+		if diags.HasError() {
+			return nil, diags
+		}
+	} else if v, ok := os.LookupEnv("FUNC_TRUSTED_KEYS"); ok {
+		keys = append(keys, v)
+	}
+
+	keyring, err := loadKeyring(keys)
+	if err != nil {
+		diags.AddAttributeError(path.Root("trusted_keys"), "Cannot load trusted keys.", err.Error())
+		return nil, diags
+	}
 
 	var appendError func(path path.Path, summary string, detail string) = diags.AddAttributeError
 	if optimistic {
 		appendError = diags.AddAttributeWarning
 	}
 
+	// The libraries of each block, which keep the order of the configuration
+	// once the downloaded ones are fetched
+	found := make([][]Library, len(libs))
+
+	// pending is a library to fetch
+	type pending struct {
+		index int
+		ttl   time.Duration
+	}
+
+	var (
+		fetched []pending
+		inputs  []*getter.FetchInput
+	)
+
 	for i, lib := range libs {
-		p, err := getter.Fetch(ctx, &getter.FetchInput{
-			URL:  lib.Source.ValueString(),
-			Path: fetchDst,
-		})
+		libPath := path.Root("library").AtListIndex(i)
+
+		if lib.Source.IsNull() == lib.Content.IsNull() {
+			appendError(libPath, "Invalid library.", "A library must have either a source or a content, but not both.")
+
+			if diags.HasError() {
+				return nil, diags
+			}
+			continue
+		}
+
+		if !lib.Content.IsNull() {
+			inline, ok := findInlineLibrary(ctx, i, &lib, keyring, settings, &diags, appendError)
+			if diags.HasError() {
+				return nil, diags
+			}
+
+			if ok {
+				found[i] = []Library{inline}
+			}
+			continue
+		}
+
+		if !lib.Language.IsNull() {
+			appendError(libPath.AtName("language"), "Invalid library.", "The language can only be set along with the content of the library.")
+
+			if diags.HasError() {
+				return nil, diags
+			}
+			continue
+		}
+
+		ttl, err := parseCacheTTL(lib.CacheTTL.ValueString())
 		if err != nil {
+			appendError(
+				path.Root("library").AtListIndex(i).AtName("cache_ttl"),
+				"Cannot parse cache TTL.",
+				err.Error(),
+			)
+
+			if diags.HasError() {
+				return nil, diags
+			}
+			continue
+		}
+
+		fetched = append(fetched, pending{index: i, ttl: ttl})
+		inputs = append(inputs, settings.input(lib.Source.ValueString(), lib.Checksum.ValueString(), ttl))
+	}
+
+	results := fetchLibraries(ctx, debug, lock, inputs)
+	if err := evictLibraries(settings, results); err != nil {
+		appendError(path.Root("cache_max_size"), "Cannot evict cached libraries.", err.Error())
+
+		if diags.HasError() {
+			return nil, diags
+		}
+	}
+
+	for j, r := range results {
+		i, ttl := fetched[j].index, fetched[j].ttl
+		lib := libs[i]
+
+		if err := r.Err; errors.Is(err, getter.ErrLockMismatch) {
+			appendError(
+				path.Root("library").AtListIndex(i).AtName("source"),
+				"Library does not match the lock file.",
+				err.Error(),
+			)
+
+			if diags.HasError() {
+				return nil, diags
+			}
+			continue
+		} else if errors.Is(err, getter.ErrInvalidChecksum) || errors.Is(err, getter.ErrChecksumMismatch) {
+			appendError(
+				path.Root("library").AtListIndex(i).AtName("checksum"),
+				"Could not verify library.",
+				err.Error(),
+			)
+
+			if diags.HasError() {
+				return nil, diags
+			}
+			continue
+		} else if err != nil {
 			appendError(
 				path.Root("library").AtListIndex(i).AtName("source"),
 				"Could not download library.",
@@ -177,10 +808,92 @@ func FindLibrariesInModel(model *FuncProviderModel, optimistic bool) ([]string,
 			if diags.HasError() {
 				return nil, diags
 			}
+			continue
+		}
+
+		// Untrusted libraries are never loaded, even if optimistic
+		location := fmt.Sprintf("library[%d]", i)
+
+		if err := verifyLibrary(ctx, debug, keyring, librariesOf(location, lib.Source.ValueString(), r.Files), lib.Signature.ValueString(), settings, ttl); err != nil {
+			diags.AddAttributeError(
+				path.Root("library").AtListIndex(i).AtName("signature"),
+				"Could not verify library signature.",
+				fmt.Sprintf("The library '%s' cannot be trusted: %v.", lib.Source.ValueString(), err),
+			)
+			return nil, diags
+		}
+
+		found[i] = librariesOf(location, lib.Source.ValueString(), r.Files)
+	}
+
+	order := make([]int, len(libs))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(a, b int) bool {
+		return libs[order[a]].Priority.ValueInt64() < libs[order[b]].Priority.ValueInt64()
+	})
+
+	libraries := make([]Library, 0)
+	for _, i := range order {
+		libraries = append(libraries, found[i]...)
+	}
+
+	if err := lock.Save(); err != nil {
+		appendError(path.Root("lock_file"), "Cannot save lock file.", err.Error())
+	}
+
+	return libraries, diags
+}
+
+// findInlineLibrary prepares a library written inline in a provider model.
+// It is verified like a downloaded library, but it is never locked, since
+// it is part of the configuration.
+//
+// It reports whether the library can be parsed.
+func findInlineLibrary(
+	ctx context.Context,
+	i int,
+	lib *LibraryModel,
+	keyring *getter.Keyring,
+	settings *fetchSettings,
+	diags *diag.Diagnostics,
+	appendError func(path path.Path, summary string, detail string),
+) (Library, bool) {
+	libPath := path.Root("library").AtListIndex(i)
+
+	inline := Library{
+		Content:  lib.Content.ValueString(),
+		Language: defaultLanguage,
+		Source:   fmt.Sprintf("library[%d].content", i),
+		Location: fmt.Sprintf("library[%d]", i),
+	}
+	if !lib.Language.IsNull() {
+		inline.Language = lib.Language.ValueString()
+	}
+
+	if !lib.CacheTTL.IsNull() {
+		appendError(libPath.AtName("cache_ttl"), "Invalid library.", "A library written inline is not cached, so it cannot have a cache TTL.")
+		return Library{}, false
+	}
+
+	if checksum := lib.Checksum.ValueString(); checksum != "" {
+		if err := getter.VerifyChecksum([]byte(inline.Content), checksum); err != nil {
+			appendError(libPath.AtName("checksum"), "Could not verify library.", err.Error())
+			return Library{}, false
 		}
+	}
 
-		paths = append(paths, p)
+	// Untrusted libraries are never loaded, even if optimistic
+	if err := verifyLibrary(ctx, tflogDebug(ctx), keyring, []Library{inline}, lib.Signature.ValueString(), settings, 0); err != nil {
+		diags.AddAttributeError(
+			libPath.AtName("signature"),
+			"Could not verify library signature.",
+			fmt.Sprintf("The library '%s' cannot be trusted: %v.", inline.Source, err),
+		)
+		return Library{}, false
 	}
 
-	return paths, diags
+	return inline, true
 }
@@ -3,8 +3,6 @@ package provider
 import (
 	"context"
 	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
 
 	"terraform-provider-func/internal/javascript"
@@ -14,6 +12,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
 	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
@@ -33,17 +32,47 @@ type FuncProvider struct {
 	version string
 	vms     map[string]runtime.Runtime
 	parsed  map[string]struct{}
+
+	// index holds the functions of all the runtimes, and the library
+	// each of them was defined by.
+	index *functionIndex
+
+	// conflicts holds the functions defined by several libraries found in
+	// the environment, which are reported on Configure.
+	conflicts []functionConflict
+
+	// diags holds the diagnostics raised while loading the libraries
+	// found in the environment, which are reported on Configure.
+	diags diag.Diagnostics
 }
 
 // FuncProviderModel describes the provider data model.
 type FuncProviderModel struct {
-	CachePath types.String `tfsdk:"cache_path"`
-	Library   types.List   `tfsdk:"library"`
+	CachePath      types.String `tfsdk:"cache_path"`
+	CacheMaxSize   types.Int64  `tfsdk:"cache_max_size"`
+	Offline        types.Bool   `tfsdk:"offline"`
+	LockFile       types.String `tfsdk:"lock_file"`
+	TrustedKeys    types.List   `tfsdk:"trusted_keys"`
+	ConflictPolicy types.String `tfsdk:"conflict_policy"`
+	Mirror         types.List   `tfsdk:"mirror"`
+	Library        types.List   `tfsdk:"library"`
+}
+
+// MirrorModel describes the mirror data model.
+type MirrorModel struct {
+	Prefix types.String `tfsdk:"prefix"`
+	Path   types.String `tfsdk:"path"`
 }
 
 // LibraryModel describes the library data model.
 type LibraryModel struct {
-	Source types.String `tfsdk:"source"`
+	Source    types.String `tfsdk:"source"`
+	Content   types.String `tfsdk:"content"`
+	Language  types.String `tfsdk:"language"`
+	Checksum  types.String `tfsdk:"checksum"`
+	Signature types.String `tfsdk:"signature"`
+	CacheTTL  types.String `tfsdk:"cache_ttl"`
+	Priority  types.Int64  `tfsdk:"priority"`
 }
 
 func (p *FuncProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -74,6 +103,75 @@ func (p *FuncProvider) Schema(ctx context.Context, req provider.SchemaRequest, r
 						"Path to the local cache directory.",
 						"If not set, it defaults to `$XDG_CACHE_HOME/func/libraries`.",
 						"Can also be set via an environment variable `FUNC_CACHE_PATH`.",
+						"The libraries are cached by content, along with the source they were downloaded from and when.",
+						"Setting the `FUNC_CACHE_REFRESH` environment variable to `true` downloads every library again.",
+					},
+					" ",
+				),
+				Optional: true,
+			},
+			"cache_max_size": schema.Int64Attribute{
+				Description: "Maximum size of the local cache directory, in bytes.",
+				MarkdownDescription: strings.Join(
+					[]string{
+						"Maximum size of the local cache directory, in bytes.",
+						"When it is exceeded, the least recently used libraries are removed from the cache.",
+						"If not set, the cache is not bounded.",
+						"Can also be set via an environment variable `FUNC_CACHE_MAX_SIZE`.",
+					},
+					" ",
+				),
+				Optional: true,
+			},
+			"offline": schema.BoolAttribute{
+				Description: "Whether libraries are only resolved from the local cache.",
+				MarkdownDescription: strings.Join(
+					[]string{
+						"Whether libraries are only resolved from the local cache, without ever using the network (e.g. in air-gapped environments).",
+						"Local files are still read, but a remote library that is not cached cannot be loaded.",
+						"The cache can be seeded by running Terraform online once with the same `cache_path`, then copying that directory.",
+						"Can also be set via an environment variable `FUNC_OFFLINE`.",
+					},
+					" ",
+				),
+				Optional: true,
+			},
+			"lock_file": schema.StringAttribute{
+				Description: "Path to the lock file of the libraries.",
+				MarkdownDescription: strings.Join(
+					[]string{
+						"Path to the lock file that records the normalized source, the resolved revision and the content hash of each library, so that later runs load exactly the same libraries.",
+						"If not set, it defaults to `.func.lock.json` (in the working directory). An empty path disables the lock file.",
+						"Can also be set via an environment variable `FUNC_LOCK_FILE`.",
+						"Libraries that do not match the lock file are not loaded, unless the `FUNC_LOCK_UPGRADE` environment variable is set to `true`,",
+						"in which case the lock file is updated.",
+					},
+					" ",
+				),
+				Optional: true,
+			},
+			"trusted_keys": schema.ListAttribute{
+				Description: "Public keys that are trusted to sign libraries.",
+				MarkdownDescription: strings.Join(
+					[]string{
+						"ASCII-armored OpenPGP public keys that are trusted to sign libraries.",
+						"If set, every library must have a `signature` made by one of these keys, otherwise the provider cannot be configured.",
+						"Can also be set via an environment variable `FUNC_TRUSTED_KEYS`.",
+					},
+					" ",
+				),
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"conflict_policy": schema.StringAttribute{
+				Description: "How functions defined by several libraries are handled.",
+				MarkdownDescription: strings.Join(
+					[]string{
+						"How functions defined by several libraries (even of different runtimes) are handled:",
+						"`error` refuses to configure the provider, `warn` uses the function of the last loaded library with a warning,",
+						"and `last-wins` silently uses the function of the last loaded library. Both libraries are reported.",
+						"If not set, it defaults to `warn`.",
+						"Can also be set via an environment variable `FUNC_CONFLICT_POLICY`.",
 					},
 					" ",
 				),
@@ -81,6 +179,47 @@ func (p *FuncProvider) Schema(ctx context.Context, req provider.SchemaRequest, r
 			},
 		},
 		Blocks: map[string]schema.Block{
+			"mirror": schema.ListNestedBlock{
+				Description: "Local directories holding copies of the libraries.",
+				MarkdownDescription: strings.Join(
+					[]string{
+						"Local directories holding copies of the libraries (like Terraform's filesystem mirrors for providers),",
+						"which are consulted before downloading any library, even in `offline` mode.",
+						"A library that is not found in its mirror is downloaded (or resolved from the cache) as usual.",
+						"Mirrors can also be set via pairs of environment variables like `FUNC_MIRROR_{ID}_PREFIX` and `FUNC_MIRROR_{ID}_PATH`,",
+						"which are ignored if any `mirror` block is set.",
+					},
+					" ",
+				),
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"prefix": schema.StringAttribute{
+							Description: "Beginning of the library sources that are mirrored.",
+							MarkdownDescription: strings.Join(
+								[]string{
+									"Beginning of the library sources that are mirrored (e.g. `git::https://github.com/org/libs.git`).",
+									"The rest of a source, without its query, is the path of the library in the mirror directory,",
+									"so `git::https://github.com/org/libs.git//functions/lib.js?ref=v2` is read from `functions/lib.js`.",
+									"When several mirrors match a source, the one with the longest prefix is used.",
+								},
+								" ",
+							),
+							Required: true,
+						},
+						"path": schema.StringAttribute{
+							Description: "Path to the local mirror directory.",
+							MarkdownDescription: strings.Join(
+								[]string{
+									"Path to the local mirror directory.",
+									"If a source is exactly the prefix, it can also be the path of the library itself.",
+								},
+								" ",
+							),
+							Required: true,
+						},
+					},
+				},
+			},
 			"library": schema.ListNestedBlock{
 				MarkdownDescription: "Configuration for the functions library.",
 				Description:         "Configuration for the functions library.",
@@ -92,14 +231,101 @@ func (p *FuncProvider) Schema(ctx context.Context, req provider.SchemaRequest, r
 								[]string{
 									"Source of the library file.\n",
 									"The source of the library file can be any [getter](https://github.com/hashicorp/go-getter#url-format) accepted URL (similar to Terraform module's sources).",
+									"It can also point at a directory or an archive, and select some of their files with a subdirectory or a glob",
+									"(e.g. `file://./lib/*.js` or `git::https://github.com/org/repo.git//functions?ref=v2`),",
+									"in which case every file is parsed by the runtime of its extension (and files that no runtime can parse are skipped).",
 									"It can also be set via an environment variable like `FUNC_LIBRARY_{ID}_SOURCE`,",
 									"where the `{ID}` value can be replaced with anything.",
 									"The provider doesn't really care about this, as long as it is prefixed with the",
 									"`FUNC_LIBRARY_` prefix, it will be found and read accordingly.",
+									"Either `source` or `content` must be set.",
 								},
 								" ",
 							),
-							Required: true,
+							Optional: true,
+						},
+						"content": schema.StringAttribute{
+							Description: "Content of the library, written inline.",
+							MarkdownDescription: strings.Join(
+								[]string{
+									"Content of the library, written inline (e.g. with a heredoc or `file()`) instead of being downloaded from a `source`.",
+									"Either `source` or `content` must be set.",
+									"A library written inline is verified against its `checksum` and `signature` like any other library,",
+									"but it is neither cached nor recorded in the lock file, since it is part of the configuration.",
+								},
+								" ",
+							),
+							Optional: true,
+						},
+						"language": schema.StringAttribute{
+							Description: "Language of the library written inline.",
+							MarkdownDescription: strings.Join(
+								[]string{
+									"Language of the library written inline in `content`, given as the file extension of its runtime (e.g. `js`).",
+									"If not set, it defaults to `js`.",
+								},
+								" ",
+							),
+							Optional: true,
+						},
+						"checksum": schema.StringAttribute{
+							Description: "Checksum of the library file.",
+							MarkdownDescription: strings.Join(
+								[]string{
+									"Checksum of the library file, in the `type:value` format (e.g. `sha256:2c26b46b...`),",
+									"where the type is either `sha256` or `sha512` (weaker types like `md5` and `sha1` are refused). Only a library of a single file can have a checksum.",
+									"If set, the library is verified every time it is loaded, even when it is found in the cache,",
+									"and a library that does not match the checksum is not loaded.",
+									"It can also be set via an environment variable like `FUNC_LIBRARY_{ID}_CHECKSUM`,",
+									"where the `{ID}` value is the one of the matching `FUNC_LIBRARY_{ID}_SOURCE` variable.",
+								},
+								" ",
+							),
+							Optional: true,
+						},
+						"signature": schema.StringAttribute{
+							Description: "Source of the detached signature of the library file.",
+							MarkdownDescription: strings.Join(
+								[]string{
+									"Source of the detached OpenPGP signature (armored or binary) of the library file,",
+									"which can be any URL accepted by `source` (e.g. `https://example.com/lib.js.sig`).",
+									"The signature must be made by one of the `trusted_keys`, and only a library of a single file can be signed.",
+									"It can also be set via an environment variable like `FUNC_LIBRARY_{ID}_SIGNATURE`,",
+									"where the `{ID}` value is the one of the matching `FUNC_LIBRARY_{ID}_SOURCE` variable.",
+								},
+								" ",
+							),
+							Optional: true,
+						},
+						"cache_ttl": schema.StringAttribute{
+							Description: "How long the library is cached before it is downloaded again.",
+							MarkdownDescription: strings.Join(
+								[]string{
+									"How long the library is cached before it is downloaded again, as a duration (e.g. `1h30m`).",
+									"This is useful for sources that change over time, like a git branch (`?ref=main`).",
+									"If not set, the library is cached forever.",
+									"It can also be set via an environment variable like `FUNC_LIBRARY_{ID}_CACHE_TTL`,",
+									"where the `{ID}` value is the one of the matching `FUNC_LIBRARY_{ID}_SOURCE` variable.",
+								},
+								" ",
+							),
+							Optional: true,
+						},
+						"priority": schema.Int64Attribute{
+							Description: "Priority of the library, which is loaded after the libraries of lower priority.",
+							MarkdownDescription: strings.Join(
+								[]string{
+									"Priority of the library. Libraries are loaded by ascending priority, then in the order of the configuration,",
+									"so that a library of higher priority wins when several libraries define the same function (see `conflict_policy`).",
+									"If not set, it defaults to `0`.",
+									"It can also be set via an environment variable like `FUNC_LIBRARY_{ID}_PRIORITY`,",
+									"where the `{ID}` value is the one of the matching `FUNC_LIBRARY_{ID}_SOURCE` variable.",
+									"The libraries found in the environment are sorted by priority, then by ID, and are always loaded",
+									"before the libraries of the configuration.",
+								},
+								" ",
+							),
+							Optional: true,
 						},
 					},
 				},
@@ -111,6 +337,15 @@ func (p *FuncProvider) Schema(ctx context.Context, req provider.SchemaRequest, r
 func (p *FuncProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
 	tflog.Trace(ctx, "Initiating configuration")
 
+	// The libraries found in the environment might not have been loaded
+	resp.Diagnostics.Append(p.diags...)
+	if resp.Diagnostics.HasError() {
+		tflog.Error(ctx, "could not load libraries found in environment", map[string]any{
+			"error": formatDiagnostics(resp.Diagnostics).Error(),
+		})
+		return
+	}
+
 	var data FuncProviderModel
 
 	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
@@ -121,7 +356,20 @@ func (p *FuncProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 		return
 	}
 
-	paths, diags := FindLibrariesInModel(&data, true)
+	policy, err := loadConflictPolicy()
+	if !data.ConflictPolicy.IsNull() && !data.ConflictPolicy.IsUnknown() {
+		policy, err = parseConflictPolicy(data.ConflictPolicy.ValueString())
+	}
+
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("conflict_policy"), "Invalid conflict policy.", err.Error())
+		tflog.Error(ctx, "invalid conflict policy", map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	libraries, diags := FindLibrariesInModel(ctx, &data, true)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		tflog.Error(ctx, "could not find libraries in configuration", map[string]any{
@@ -130,16 +378,20 @@ func (p *FuncProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 		return
 	}
 
-	for _, path := range paths {
-		if _, ok := p.parsed[path]; ok {
-			// This path was already parsed once.
+	// The conflicts of the environment, along with the ones of this configuration
+	conflicts := append([]functionConflict{}, p.conflicts...)
+
+	for _, lib := range libraries {
+		path := lib.name()
+		if _, ok := p.parsed[lib.key()]; ok {
+			// This library was already parsed once.
 			tflog.Debug(ctx, "library already indexed", map[string]any{
 				"path": path,
 			})
 			continue
 		}
 
-		content, err := os.ReadFile(path)
+		content, err := lib.read()
 		if err != nil {
 			resp.Diagnostics.AddWarning("Cannot read file.", err.Error())
 			tflog.Warn(ctx, "Cannot read library", map[string]any{
@@ -149,9 +401,16 @@ func (p *FuncProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 			continue
 		}
 
-		vmKey := filepath.Ext(path)
+		vmKey := lib.runtime()
 		vm, ok := p.vms[vmKey]
-		if !ok {
+		if !ok && lib.Expanded {
+			// Sources of several files can hold anything (e.g. a README)
+			tflog.Debug(ctx, "Skipping file that no VM can parse", map[string]any{
+				"path":   path,
+				"source": lib.Source,
+			})
+			continue
+		} else if !ok {
 			resp.Diagnostics.AddWarning(
 				"Cannot parse library.",
 				fmt.Sprintf("There is no parser that can parse '.%s' files (source '%s').", vmKey, path),
@@ -164,7 +423,11 @@ func (p *FuncProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 			continue
 		}
 
-		if err := vm.Parse(string(content)); err != nil {
+		before := snapshot(vm)
+
+		ds := vm.Parse(path, content)
+		if ds.HasError() {
+			err := formatDiagnostics(ds)
 			resp.Diagnostics.AddWarning(
 				"Library is unparsable.",
 				fmt.Sprintf("Built-in VM could not parse library '%s': %v.", path, err.Error()),
@@ -177,7 +440,18 @@ func (p *FuncProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 			continue
 		}
 
-		p.parsed[path] = struct{}{}
+		resp.Diagnostics.Append(ds...)
+
+		p.parsed[lib.key()] = struct{}{}
+
+		for _, c := range p.index.update(vm, before, lib.origin()) {
+			tflog.Debug(ctx, "Function overridden by library", map[string]any{
+				"function": c.name,
+				"previous": c.previous,
+				"current":  c.current,
+			})
+			conflicts = append(conflicts, c)
+		}
 
 		tflog.Info(ctx, "Successfully indexed library", map[string]any{
 			"path": path,
@@ -185,12 +459,20 @@ func (p *FuncProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 		})
 	}
 
+	// Conflicts are reported once every library is loaded, including the
+	// ones found in the environment
+	reportConflicts(policy, conflicts, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		tflog.Error(ctx, "several libraries define the same function", map[string]any{
+			"error": formatDiagnostics(resp.Diagnostics).Error(),
+		})
+		return
+	}
+
 	funcs := make(map[string]runtime.Function, 0)
 
-	for _, vm := range p.vms {
-		for _, f := range vm.Functions() {
-			funcs[f.Name()] = f
-		}
+	for _, f := range p.index.functions() {
+		funcs[f.Name()] = f
 	}
 
 	tflog.Info(ctx, "Provider indexed functions", map[string]any{
@@ -226,11 +508,7 @@ func (p *FuncProvider) DataSources(ctx context.Context) []func() datasource.Data
 func (p *FuncProvider) Functions(ctx context.Context) []func() function.Function {
 	tflog.Trace(ctx, "Exposing functions")
 
-	funcs := make([]runtime.Function, 0)
-
-	for _, runtime := range p.vms {
-		funcs = append(funcs, runtime.Functions()...)
-	}
+	funcs := p.index.functions()
 
 	tflog.Info(ctx, "Provider indexed functions", map[string]any{
 		"count": len(funcs),
@@ -258,32 +536,41 @@ func New(version string) func() provider.Provider {
 	}
 
 	parsed := make(map[string]struct{})
+	index := newFunctionIndex()
+	conflicts := make([]functionConflict, 0)
 
 	diags := diag.Diagnostics{}
 
-	paths, ds := FindLibrariesInEnvironment(true)
+	// Errors are reported when the provider is configured, since
+	// they cannot be reported yet
+	libraries, ds := FindLibrariesInEnvironment(context.Background(), logger, true)
 	if ds.HasError() {
 		logger.Error(formatDiagnostics(ds).Error(), "diagnostics", ds)
-		return nil
 	}
+	diags.Append(ds...)
 
-	for _, path := range paths {
-		if _, ok := parsed[path]; ok {
-			// This path was already parsed once.
+	for _, lib := range libraries {
+		path := lib.name()
+		if _, ok := parsed[lib.key()]; ok {
+			// This library was already parsed once.
 			logger.Debug("skipping already parsed library", "library", path)
 			continue
 		}
 
-		content, err := os.ReadFile(path)
+		content, err := lib.read()
 		if err != nil {
 			logger.Warn("cannot read file", "error", err)
 			diags.AddWarning("Cannot read file.", err.Error())
 			continue
 		}
 
-		vmKey := strings.TrimPrefix(filepath.Ext(path), ".")
+		vmKey := lib.runtime()
 		vm, ok := vms[vmKey]
-		if !ok {
+		if !ok && lib.Expanded {
+			// Sources of several files can hold anything (e.g. a README)
+			logger.Debug("skipping file without parser", "parser", vmKey, "path", path, "source", lib.Source)
+			continue
+		} else if !ok {
 			logger.Warn("no parser for file", "parser", vmKey, "path", path)
 			diags.AddWarning(
 				"Cannot parse library.",
@@ -292,7 +579,11 @@ func New(version string) func() provider.Provider {
 			continue
 		}
 
-		if err := vm.Parse(string(content)); err != nil {
+		before := snapshot(vm)
+
+		ds := vm.Parse(path, content)
+		if ds.HasError() {
+			err := formatDiagnostics(ds)
 			logger.Warn("unparsable library", "parser", vmKey, "path", path, "error", err.Error())
 			diags.AddWarning(
 				"Library is unparsable.",
@@ -301,22 +592,34 @@ func New(version string) func() provider.Provider {
 			continue
 		}
 
+		for _, d := range ds {
+			logger.Warn("library parsed with warnings", "parser", vmKey, "path", path, "summary", d.Summary(), "detail", d.Detail())
+		}
+		diags.Append(ds...)
+
+		for _, c := range index.update(vm, before, lib.origin()) {
+			logger.Warn("function overridden by library", "function", c.name, "previous", c.previous, "current", c.current)
+			conflicts = append(conflicts, c)
+		}
+
 		logger.Info("successfully parsed library", "path", path)
-		parsed[path] = struct{}{}
+		parsed[lib.key()] = struct{}{}
 	}
 
 	if diags.HasError() {
-		logger.Error(formatDiagnostics(ds).Error(), "diagnostics", ds)
-		return nil
+		logger.Error(formatDiagnostics(diags).Error(), "diagnostics", diags)
+	} else {
+		logger.Info("all libraries were successfully indexed", "vms", maps.Keys(vms), "parsed", maps.Keys(parsed))
 	}
 
-	logger.Info("all libraries were successfully indexed", "vms", maps.Keys(vms), "parsed", maps.Keys(parsed))
-
 	return func() provider.Provider {
 		return &FuncProvider{
-			version: version,
-			vms:     vms,
-			parsed:  parsed,
+			version:   version,
+			vms:       vms,
+			parsed:    parsed,
+			index:     index,
+			conflicts: conflicts,
+			diags:     diags,
 		}
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"testing"
 
@@ -25,6 +26,8 @@ func TestFuncProvider(t *testing.T) {
 	// Cannot use t.Setenv with t.Parallel
 	//nolint:tenv
 	os.Setenv("FUNC_LIBRARY_TEST01_SOURCE", libPath)
+	//nolint:tenv
+	os.Setenv("FUNC_LOCK_FILE", filepath.Join(t.TempDir(), ".func.lock.json"))
 
 	t.Parallel()
 
@@ -78,6 +81,105 @@ func TestFuncProvider(t *testing.T) {
 					})),
 				},
 			},
+			{
+				Config: fmt.Sprintf(`
+				provider "func" {
+					library {
+						source = "%s"
+					}
+				}
+
+				data "func" "extend" {
+					id = "extend"
+
+					inputs = [["a"], ["b", "c"]]
+				}
+				`, libPath),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("data.func.extend", tfjsonpath.New("result"), knownvalue.ListExact([]knownvalue.Check{
+						knownvalue.StringExact("a"),
+						knownvalue.StringExact("b"),
+						knownvalue.StringExact("c"),
+					})),
+				},
+			},
+			{
+				Config: `
+				provider "func" {
+					library {
+						content = <<-EOT
+							$(function shout(s) {
+								return s.toUpperCase() + "!";
+							})
+						EOT
+					}
+				}
+
+				data "func" "shout" {
+					id = "shout"
+
+					inputs = ["hello"]
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("data.func.shout", tfjsonpath.New("result"), knownvalue.StringExact("HELLO!")),
+				},
+			},
+			{
+				Config: `
+				provider "func" {
+					conflict_policy = "last-wins"
+
+					library {
+						priority = 1
+						content  = <<-EOT
+							$(function pick() { return "high"; })
+						EOT
+					}
+
+					library {
+						content = <<-EOT
+							$(function pick() { return "low"; })
+						EOT
+					}
+				}
+
+				data "func" "pick" {
+					id = "pick"
+
+					inputs = []
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("data.func.pick", tfjsonpath.New("result"), knownvalue.StringExact("high")),
+				},
+			},
+			{
+				Config: `
+				provider "func" {
+					conflict_policy = "error"
+
+					library {
+						content = <<-EOT
+							$(function pick() { return "first"; })
+						EOT
+					}
+
+					library {
+						content = <<-EOT
+							$(function pick() { return "second"; })
+						EOT
+					}
+				}
+
+				data "func" "pick" {
+					id = "pick"
+
+					inputs = []
+				}
+				`,
+				ExpectError: regexp.MustCompile("Conflicting function"),
+			},
 		},
 	})
 
@@ -2,6 +2,7 @@ package runtime
 
 import (
 	"context"
+	"terraform-provider-func/tftypes/tfarg"
 	"terraform-provider-func/tftypes/tfconvert"
 
 	"github.com/hashicorp/terraform-plugin-framework/attr"
@@ -27,6 +28,14 @@ type Function interface {
 	// formatted as markdown
 	MarkdownDescription() string
 
+	// DeprecationMessage returns the reason why the function is deprecated
+	// An empty message means the function is not deprecated.
+	DeprecationMessage() string
+
+	// ConversionOptions returns the options used to convert the function result
+	// into the return type (e.g. whether extra object attributes are dropped)
+	ConversionOptions() tfconvert.Options
+
 	// AllocateParameters should allocate objects to which the function parameters can be bound
 	AllocateParameters() ([]any, error)
 
@@ -65,7 +74,9 @@ func (r TerraformFunction) Definition(_ context.Context, _ tffunc.DefinitionRequ
 
 	resp.Definition = tffunc.Definition{
 		Summary:             r.Function.Summary(),
-		MarkdownDescription: r.Function.Description(),
+		Description:         r.Function.Description(),
+		MarkdownDescription: r.Function.MarkdownDescription(),
+		DeprecationMessage:  r.Function.DeprecationMessage(),
 		Parameters:          params,
 		Return:              ret,
 	}
@@ -78,9 +89,12 @@ func (r TerraformFunction) Run(ctx context.Context, req tffunc.RunRequest, resp
 		return
 	}
 
-	resp.Error = tffunc.ConcatFuncErrors(req.Arguments.Get(ctx, args...))
-	if resp.Error != nil {
-		return
+	// Functions without parameters do not receive argument data
+	if len(args) > 0 {
+		resp.Error = tffunc.ConcatFuncErrors(req.Arguments.Get(ctx, args...))
+		if resp.Error != nil {
+			return
+		}
 	}
 
 	res, err := r.Function.Execute(args...)
@@ -95,7 +109,15 @@ func (r TerraformFunction) Run(ctx context.Context, req tffunc.RunRequest, resp
 		return
 	}
 
-	val, err := tfconvert.Convert(ctx, res.(attr.Value), rty.GetType()) //nolint:forcetypeassert
+	// The result is converted to the declared type first, which can be
+	// more specific than the return type (e.g. tuples are dynamic returns)
+	val, err := tfconvert.ConvertWithOptions(ctx, res.(attr.Value), tfarg.ReturnType(rty), r.Function.ConversionOptions()) //nolint:forcetypeassert
+	if err != nil {
+		resp.Error = tffunc.ConcatFuncErrors(resp.Error, tffunc.NewFuncError(err.Error()))
+		return
+	}
+
+	val, err = tfconvert.Convert(ctx, val, rty.GetType())
 	if err != nil {
 		resp.Error = tffunc.ConcatFuncErrors(resp.Error, tffunc.NewFuncError(err.Error()))
 		return
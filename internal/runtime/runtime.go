@@ -1,5 +1,7 @@
 package runtime
 
+import "github.com/hashicorp/terraform-plugin-framework/diag"
+
 // Runtime is an abstract interface that represents the behavior
 // of a func runtime.
 type Runtime interface {
@@ -8,7 +10,11 @@ type Runtime interface {
 	// A runtime can be called multiple time to parse different
 	// (or even the same) sources. The runtime should handle the
 	// overrides and make sure repetitive calls are allowed.
-	Parse(src string) error
+	//
+	// The path is the location of the source and it is only used
+	// to give context to the diagnostics. Issues that do not prevent
+	// the source from being used should be reported as warnings.
+	Parse(path string, src string) diag.Diagnostics
 
 	// Functions returns a slice of Terraform-compatible functions.
 	Functions() []Function
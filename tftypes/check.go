@@ -3,6 +3,7 @@ package tftypes
 import (
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"golang.org/x/exp/maps"
 )
 
 // IsBoolType checks if a type is a bool.
@@ -61,6 +62,13 @@ func IsMapType(ty attr.Type) bool {
 	return okV || okP
 }
 
+// IsDynamicType checks if a type is dynamic.
+func IsDynamicType(ty attr.Type) bool {
+	_, okV := ty.(basetypes.DynamicType)
+	_, okP := ty.(*basetypes.DynamicType)
+	return okV || okP
+}
+
 // PlainTypeString takes a type and returns a representative string.
 //
 // Compared to the built-in String() method of the attr.Type interface,
@@ -101,42 +109,151 @@ func PlainTypeString(ty attr.Type) string {
 	return "basetypes.DynamicType"
 }
 
-// TypeEqual checks if two types are equal.
+// TypeEqual checks if two types are structurally equal.
 //
 // Compared with the built-in Equal method, this method also returns
-// true, if one of the types is pointer and the other one not.
+// true, if one of the types is pointer and the other one not. Element,
+// attribute and tuple types are compared recursively.
 func TypeEqual(lhs attr.Type, rhs attr.Type) bool {
-	if IsBoolType(lhs) && IsBoolType(rhs) {
-		return true
+	if lhs == nil || rhs == nil {
+		return lhs == nil && rhs == nil
 	}
 
-	if IsNumberType(lhs) && IsNumberType(rhs) {
-		return true
+	if PlainTypeString(lhs) != PlainTypeString(rhs) {
+		return false
 	}
 
-	if IsStringType(lhs) && IsStringType(rhs) {
+	switch l := EnsureTypePointer(lhs).(type) {
+	case *basetypes.TupleType:
+		r := EnsureTypePointer(rhs).(*basetypes.TupleType) //nolint:forcetypeassert
+		return typesEqual(l.ElementTypes(), r.ElementTypes())
+	case *basetypes.ListType:
+		return TypeEqual(l.ElementType(), EnsureTypePointer(rhs).(*basetypes.ListType).ElementType()) //nolint:forcetypeassert
+	case *basetypes.SetType:
+		return TypeEqual(l.ElementType(), EnsureTypePointer(rhs).(*basetypes.SetType).ElementType()) //nolint:forcetypeassert
+	case *basetypes.MapType:
+		return TypeEqual(l.ElementType(), EnsureTypePointer(rhs).(*basetypes.MapType).ElementType()) //nolint:forcetypeassert
+	case *basetypes.ObjectType:
+		la := l.AttributeTypes()
+		ra := EnsureTypePointer(rhs).(*basetypes.ObjectType).AttributeTypes() //nolint:forcetypeassert
+
+		if len(la) != len(ra) {
+			return false
+		}
+
+		for k, lty := range la {
+			rty, ok := ra[k]
+			if !ok || !TypeEqual(lty, rty) {
+				return false
+			}
+		}
+
 		return true
 	}
 
-	if IsTupleType(lhs) && IsTupleType(rhs) {
-		return true
+	// Primitive and dynamic types have no inner types
+	return true
+}
+
+// TypeAssignable checks if a value of type "from" can be used where
+// a value of type "to" is expected, without converting any primitive.
+//
+// Every type is assignable to a dynamic type. Tuples are assignable to lists
+// and sets if all of their elements are assignable to the element type, and
+// objects are assignable to maps in the same way. Collections, tuples and
+// objects are otherwise assignable to the same kind of type when their
+// element, attribute and tuple types are assignable.
+func TypeAssignable(from attr.Type, to attr.Type) bool {
+	if from == nil || to == nil {
+		return false
 	}
 
-	if IsListType(lhs) && IsListType(rhs) {
+	if IsDynamicType(to) {
 		return true
 	}
 
-	if IsSetType(lhs) && IsSetType(rhs) {
+	switch t := EnsureTypePointer(to).(type) {
+	case *basetypes.TupleType:
+		f, ok := EnsureTypePointer(from).(*basetypes.TupleType)
+		if !ok || len(f.ElementTypes()) != len(t.ElementTypes()) {
+			return false
+		}
+
+		for i, ety := range f.ElementTypes() {
+			if !TypeAssignable(ety, t.ElementTypes()[i]) {
+				return false
+			}
+		}
+
 		return true
-	}
+	case *basetypes.ListType:
+		return sequenceAssignable(from, t.ElementType(), IsListType)
+	case *basetypes.SetType:
+		return sequenceAssignable(from, t.ElementType(), IsSetType)
+	case *basetypes.MapType:
+		switch f := EnsureTypePointer(from).(type) {
+		case *basetypes.MapType:
+			return TypeAssignable(f.ElementType(), t.ElementType())
+		case *basetypes.ObjectType:
+			return allAssignable(maps.Values(f.AttributeTypes()), t.ElementType())
+		}
+
+		return false
+	case *basetypes.ObjectType:
+		f, ok := EnsureTypePointer(from).(*basetypes.ObjectType)
+		if !ok || len(f.AttributeTypes()) != len(t.AttributeTypes()) {
+			return false
+		}
+
+		for k, fty := range f.AttributeTypes() {
+			tty, ok := t.AttributeTypes()[k]
+			if !ok || !TypeAssignable(fty, tty) {
+				return false
+			}
+		}
 
-	if IsObjectType(lhs) && IsObjectType(rhs) {
 		return true
 	}
 
-	if IsMapType(lhs) && IsMapType(rhs) {
-		return true
+	return TypeEqual(from, to)
+}
+
+// sequenceAssignable checks if a type is assignable to a list or set
+// (identified by isKind) with the given element type.
+func sequenceAssignable(from attr.Type, elem attr.Type, isKind func(attr.Type) bool) bool {
+	if f, ok := EnsureTypePointer(from).(*basetypes.TupleType); ok {
+		return allAssignable(f.ElementTypes(), elem)
+	}
+
+	if !isKind(from) {
+		return false
+	}
+
+	return TypeAssignable(EnsureTypePointer(from).(attr.TypeWithElementType).ElementType(), elem) //nolint:forcetypeassert
+}
+
+// allAssignable checks if all types of a slice are assignable to a type.
+func allAssignable(tys []attr.Type, to attr.Type) bool {
+	for _, ty := range tys {
+		if !TypeAssignable(ty, to) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// typesEqual checks if two slices of types are equal, position by position.
+func typesEqual(lhs []attr.Type, rhs []attr.Type) bool {
+	if len(lhs) != len(rhs) {
+		return false
+	}
+
+	for i := range lhs {
+		if !TypeEqual(lhs[i], rhs[i]) {
+			return false
+		}
 	}
 
-	return false
+	return true
 }
@@ -0,0 +1,104 @@
+package tftypes
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+var (
+	stringType  = basetypes.StringType{}
+	numberType  = basetypes.NumberType{}
+	dynamicType = basetypes.DynamicType{}
+)
+
+func TestTypeEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		lhs  attr.Type
+		rhs  attr.Type
+		want bool
+	}{
+		{"Same primitive", stringType, stringType, true},
+		{"Pointer and value", &basetypes.StringType{}, stringType, true},
+		{"Different primitives", stringType, numberType, false},
+		{"Dynamic", dynamicType, dynamicType, true},
+		{"Lists of the same element", basetypes.ListType{ElemType: stringType}, basetypes.ListType{ElemType: stringType}, true},
+		{"Lists of different elements", basetypes.ListType{ElemType: stringType}, basetypes.ListType{ElemType: numberType}, false},
+		{"Nested lists", basetypes.ListType{ElemType: basetypes.ListType{ElemType: stringType}}, basetypes.ListType{ElemType: basetypes.ListType{ElemType: numberType}}, false},
+		{"List and set", basetypes.ListType{ElemType: stringType}, basetypes.SetType{ElemType: stringType}, false},
+		{"Maps of different elements", basetypes.MapType{ElemType: stringType}, basetypes.MapType{ElemType: numberType}, false},
+		{"Tuples of the same elements", basetypes.TupleType{ElemTypes: []attr.Type{stringType, numberType}}, basetypes.TupleType{ElemTypes: []attr.Type{stringType, numberType}}, true},
+		{"Tuples of different elements", basetypes.TupleType{ElemTypes: []attr.Type{stringType, numberType}}, basetypes.TupleType{ElemTypes: []attr.Type{numberType, stringType}}, false},
+		{"Tuples of different length", basetypes.TupleType{ElemTypes: []attr.Type{stringType}}, basetypes.TupleType{ElemTypes: []attr.Type{stringType, stringType}}, false},
+		{"Objects of the same attributes", basetypes.ObjectType{AttrTypes: map[string]attr.Type{"a": stringType}}, basetypes.ObjectType{AttrTypes: map[string]attr.Type{"a": stringType}}, true},
+		{"Objects of different attribute types", basetypes.ObjectType{AttrTypes: map[string]attr.Type{"a": stringType}}, basetypes.ObjectType{AttrTypes: map[string]attr.Type{"a": numberType}}, false},
+		{"Objects of different attributes", basetypes.ObjectType{AttrTypes: map[string]attr.Type{"a": stringType}}, basetypes.ObjectType{AttrTypes: map[string]attr.Type{"b": stringType}}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := TypeEqual(test.lhs, test.rhs); got != test.want {
+				t.Errorf("TypeEqual(%v, %v): want %v, got %v", test.lhs, test.rhs, test.want, got)
+			}
+
+			if got := TypeEqual(test.rhs, test.lhs); got != test.want {
+				t.Errorf("TypeEqual(%v, %v): want %v, got %v", test.rhs, test.lhs, test.want, got)
+			}
+		})
+	}
+}
+
+func TestTypeAssignable(t *testing.T) {
+	tests := []struct {
+		name string
+		from attr.Type
+		to   attr.Type
+		want bool
+	}{
+		{"Same primitive", stringType, stringType, true},
+		{"Different primitives", numberType, stringType, false},
+		{"Anything to dynamic", basetypes.ListType{ElemType: stringType}, dynamicType, true},
+		{"Dynamic to primitive", dynamicType, stringType, false},
+		{"Tuple to list", basetypes.TupleType{ElemTypes: []attr.Type{stringType, stringType}}, basetypes.ListType{ElemType: stringType}, true},
+		{"Mixed tuple to list", basetypes.TupleType{ElemTypes: []attr.Type{stringType, numberType}}, basetypes.ListType{ElemType: stringType}, false},
+		{"Empty tuple to set", basetypes.TupleType{ElemTypes: []attr.Type{}}, basetypes.SetType{ElemType: stringType}, true},
+		{"List to tuple", basetypes.ListType{ElemType: stringType}, basetypes.TupleType{ElemTypes: []attr.Type{stringType}}, false},
+		{"List to set", basetypes.ListType{ElemType: stringType}, basetypes.SetType{ElemType: stringType}, false},
+		{"Nested lists", basetypes.ListType{ElemType: basetypes.ListType{ElemType: stringType}}, basetypes.ListType{ElemType: basetypes.ListType{ElemType: numberType}}, false},
+		{"List to list of dynamic", basetypes.ListType{ElemType: stringType}, basetypes.ListType{ElemType: dynamicType}, true},
+		{"Tuple to tuple", basetypes.TupleType{ElemTypes: []attr.Type{basetypes.TupleType{ElemTypes: []attr.Type{stringType}}}}, basetypes.TupleType{ElemTypes: []attr.Type{basetypes.ListType{ElemType: stringType}}}, true},
+		{"Object to map", basetypes.ObjectType{AttrTypes: map[string]attr.Type{"a": stringType, "b": stringType}}, basetypes.MapType{ElemType: stringType}, true},
+		{"Mixed object to map", basetypes.ObjectType{AttrTypes: map[string]attr.Type{"a": stringType, "b": numberType}}, basetypes.MapType{ElemType: stringType}, false},
+		{"Map to object", basetypes.MapType{ElemType: stringType}, basetypes.ObjectType{AttrTypes: map[string]attr.Type{"a": stringType}}, false},
+		{"Object to object", basetypes.ObjectType{AttrTypes: map[string]attr.Type{"a": basetypes.TupleType{ElemTypes: []attr.Type{numberType}}}}, basetypes.ObjectType{AttrTypes: map[string]attr.Type{"a": basetypes.ListType{ElemType: numberType}}}, true},
+		{"Object with missing attributes", basetypes.ObjectType{AttrTypes: map[string]attr.Type{"a": stringType}}, basetypes.ObjectType{AttrTypes: map[string]attr.Type{"a": stringType, "b": stringType}}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := TypeAssignable(test.from, test.to); got != test.want {
+				t.Errorf("TypeAssignable(%v, %v): want %v, got %v", test.from, test.to, test.want, got)
+			}
+		})
+	}
+}
+
+func TestCollapseTypes(t *testing.T) {
+	list := basetypes.ListType{ElemType: stringType}
+	tuple := basetypes.TupleType{ElemTypes: []attr.Type{stringType}}
+
+	got, err := CollapseTypes([]attr.Type{tuple, list, tuple})
+	if err != nil {
+		t.Fatalf("types were expected to collapse: %v", err)
+	}
+
+	if !TypeEqual(got, list) {
+		t.Errorf("wrong collapsed type: want %v, got %v", list, got)
+	}
+
+	if _, err := CollapseTypes([]attr.Type{list, basetypes.ListType{ElemType: numberType}}); err == nil {
+		t.Errorf("lists of different element types were not expected to collapse")
+	}
+}
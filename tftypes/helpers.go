@@ -1,11 +1,13 @@
 package tftypes
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	tftypesgo "github.com/hashicorp/terraform-plugin-go/tftypes"
 )
 
 // EnsurePointer makes sure that the underlying implementation
@@ -48,6 +50,9 @@ func EnsureTypePointer(t attr.Type) attr.Type {
 
 // CollapseTypes accepts a slice of types and returns a single type
 // if all elements of the slice are of the same type.
+//
+// Types are compared structurally. If a type is assignable to another one
+// (e.g. a tuple of strings to a list of strings), the broadest one is kept.
 func CollapseTypes(tys []attr.Type) (attr.Type, error) {
 	var cty attr.Type = nil
 	for _, ty := range tys {
@@ -56,14 +61,30 @@ func CollapseTypes(tys []attr.Type) (attr.Type, error) {
 			continue
 		}
 
-		if !TypeEqual(ty, cty) {
+		if TypeAssignable(ty, cty) {
+			continue
+		}
+
+		if !TypeAssignable(cty, ty) {
 			return nil, fmt.Errorf("all elements must be of the same type (%v =/= %v)", cty, ty)
 		}
+
+		cty = ty
 	}
 
 	return cty, nil
 }
 
+// NullValue returns a null value of the given type.
+func NullValue(ctx context.Context, typ attr.Type) (attr.Value, error) {
+	return typ.ValueFromTerraform(ctx, tftypesgo.NewValue(typ.TerraformType(ctx), nil))
+}
+
+// UnknownValue returns an unknown value of the given type.
+func UnknownValue(ctx context.Context, typ attr.Type) (attr.Value, error) {
+	return typ.ValueFromTerraform(ctx, tftypesgo.NewValue(typ.TerraformType(ctx), tftypesgo.UnknownValue))
+}
+
 // IgnoreDiagnostics takes as input a value and a Diagnostics object
 // and ignores the diagnostics, only returning the value.
 func IgnoreDiagnostics[T any](v T, _ diag.Diagnostics) T {
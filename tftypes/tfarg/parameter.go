@@ -1,7 +1,6 @@
 package tfarg
 
 import (
-	"fmt"
 	"terraform-provider-func/tftypes"
 
 	"github.com/hashicorp/terraform-plugin-framework/attr"
@@ -47,7 +46,20 @@ func AsTerraformParameter(typ attr.Type, name string, in *ParameterOptions) (fun
 			MarkdownDescription: in.MarkdownDescription,
 		}, nil
 	case "basetypes.TupleType":
-		return nil, fmt.Errorf("tuples cannot be configured as function parameters")
+		// Terraform does not support tuple parameters, so a dynamic parameter
+		// is used instead, with a validator that enforces the tuple type
+		return &function.DynamicParameter{
+			AllowNullValue:      true,
+			AllowUnknownValues:  false,
+			Name:                name,
+			Description:         in.Description,
+			MarkdownDescription: in.MarkdownDescription,
+			Validators: []function.DynamicParameterValidator{
+				&TupleValidator{
+					ElementTypes: tftypes.EnsureTypePointer(typ).(*basetypes.TupleType).ElementTypes(), //nolint:forcetypeassert
+				},
+			},
+		}, nil
 	case "basetypes.ListType":
 		return &function.ListParameter{
 			ElementType:         typ.(*basetypes.ListType).ElemType, //nolint:forcetypeassert
@@ -108,7 +120,11 @@ func AsTerraformReturn(typ attr.Type) (function.Return, error) {
 	case "basetypes.StringType":
 		return &function.StringReturn{}, nil
 	case "basetypes.TupleType":
-		return nil, fmt.Errorf("tuples cannot be configured as function return")
+		// Terraform does not support tuple returns, so a dynamic return is
+		// used instead, which remembers the tuple type the result must have
+		return &TupleReturn{
+			ElementTypes: tftypes.EnsureTypePointer(typ).(*basetypes.TupleType).ElementTypes(), //nolint:forcetypeassert
+		}, nil
 	case "basetypes.ListType":
 		return &function.ListReturn{
 			ElementType: typ.(*basetypes.ListType).ElemType, //nolint:forcetypeassert
@@ -131,3 +147,31 @@ func AsTerraformReturn(typ attr.Type) (function.Return, error) {
 
 	return &function.DynamicReturn{}, nil
 }
+
+// ParameterType returns the type a parameter was generated from.
+//
+// For most parameters, this is the parameter type. Parameters that Terraform
+// cannot express (e.g. tuples) are dynamic, but they still keep their type.
+func ParameterType(param function.Parameter) attr.Type {
+	if p, ok := param.(*function.DynamicParameter); ok {
+		for _, v := range p.Validators {
+			if tv, ok := v.(*TupleValidator); ok {
+				return basetypes.TupleType{ElemTypes: tv.ElementTypes}
+			}
+		}
+	}
+
+	return param.GetType()
+}
+
+// ReturnType returns the type a return was generated from.
+//
+// For most returns, this is the return type. Returns that Terraform
+// cannot express (e.g. tuples) are dynamic, but they still keep their type.
+func ReturnType(ret function.Return) attr.Type {
+	if r, ok := ret.(*TupleReturn); ok {
+		return basetypes.TupleType{ElemTypes: r.ElementTypes}
+	}
+
+	return ret.GetType()
+}
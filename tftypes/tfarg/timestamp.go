@@ -0,0 +1,32 @@
+package tfarg
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+// Ensure the timestamp implementations satisfy the framework interfaces.
+var (
+	_ function.StringParameterValidator = &TimestampValidator{}
+)
+
+// TimestampValidator validates that a string argument is an RFC 3339
+// timestamp (e.g. "2006-01-02T15:04:05Z"), as returned by Terraform's
+// timestamp function.
+type TimestampValidator struct{}
+
+func (v *TimestampValidator) ValidateParameterString(ctx context.Context, req function.StringParameterValidatorRequest, resp *function.StringParameterValidatorResponse) {
+	if req.Value.IsNull() || req.Value.IsUnknown() {
+		return
+	}
+
+	if _, err := time.Parse(time.RFC3339, req.Value.ValueString()); err != nil {
+		resp.Error = function.NewArgumentFuncError(
+			req.ArgumentPosition,
+			fmt.Sprintf("Invalid value: expected an RFC 3339 timestamp (e.g. \"2006-01-02T15:04:05Z\"), but received %q.", req.Value.ValueString()),
+		)
+	}
+}
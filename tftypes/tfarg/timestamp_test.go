@@ -0,0 +1,49 @@
+package tfarg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+func TestTimestampValidator(t *testing.T) {
+	v := &TimestampValidator{}
+
+	tests := []struct {
+		name  string
+		given basetypes.StringValue
+		err   bool
+	}{
+		{"UTC", basetypes.NewStringValue("2024-02-29T12:30:00Z"), false},
+		{"Offset and fractional seconds", basetypes.NewStringValue("2024-02-29T12:30:00.123+02:00"), false},
+		{"Date only", basetypes.NewStringValue("2024-02-29"), true},
+		{"Invalid day", basetypes.NewStringValue("2024-02-30T12:30:00Z"), true},
+		{"Not a timestamp", basetypes.NewStringValue("now"), true},
+		{"Null", basetypes.NewStringNull(), false},
+		{"Unknown", basetypes.NewStringUnknown(), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &function.StringParameterValidatorResponse{}
+			v.ValidateParameterString(context.Background(), function.StringParameterValidatorRequest{
+				ArgumentPosition: 1,
+				Value:            test.given,
+			}, resp)
+
+			if test.err && resp.Error == nil {
+				t.Errorf("validation was expected to fail")
+			}
+
+			if !test.err && resp.Error != nil {
+				t.Errorf("validation was not expected to fail: %v", resp.Error)
+			}
+
+			if resp.Error != nil && (resp.Error.FunctionArgument == nil || *resp.Error.FunctionArgument != 1) {
+				t.Errorf("error was expected to point to the argument")
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package tfarg
+
+import (
+	"context"
+	"fmt"
+	"terraform-provider-func/tftypes"
+	"terraform-provider-func/tftypes/tfconvert"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// Ensure the tuple implementations satisfy the framework interfaces.
+var (
+	_ function.Return                    = &TupleReturn{}
+	_ function.DynamicParameterValidator = &TupleValidator{}
+)
+
+// TupleReturn is a dynamic return that holds the element types of
+// the tuple the function returns.
+//
+// The result of the function must be converted to the tuple type before
+// it is returned (see ReturnType), then wrapped into a dynamic value.
+type TupleReturn struct {
+	function.DynamicReturn
+
+	ElementTypes []attr.Type
+}
+
+// TupleValidator validates that a dynamic argument is a tuple
+// with the given element types.
+//
+// Lists and sets are also accepted, as long as they have the right
+// number of elements and their elements can be converted.
+type TupleValidator struct {
+	ElementTypes []attr.Type
+}
+
+func (v *TupleValidator) ValidateParameterDynamic(ctx context.Context, req function.DynamicParameterValidatorRequest, resp *function.DynamicParameterValidatorResponse) {
+	if req.Value.IsNull() || req.Value.IsUnderlyingValueNull() || req.Value.IsUnknown() || req.Value.IsUnderlyingValueUnknown() {
+		return
+	}
+
+	val := req.Value.UnderlyingValue()
+	ty := val.Type(ctx)
+
+	var elems []attr.Value
+	switch tftypes.PlainTypeString(ty) {
+	case "basetypes.TupleType":
+		elems = tftypes.EnsurePointer(val).(*basetypes.TupleValue).Elements() //nolint:forcetypeassert
+	case "basetypes.ListType":
+		elems = tftypes.EnsurePointer(val).(*basetypes.ListValue).Elements() //nolint:forcetypeassert
+	case "basetypes.SetType":
+		elems = tftypes.EnsurePointer(val).(*basetypes.SetValue).Elements() //nolint:forcetypeassert
+	default:
+		resp.Error = function.NewArgumentFuncError(
+			req.ArgumentPosition,
+			fmt.Sprintf("Invalid value: expected a tuple of %d elements, but received a value of type %v.", len(v.ElementTypes), ty),
+		)
+		return
+	}
+
+	if len(elems) != len(v.ElementTypes) {
+		resp.Error = function.NewArgumentFuncError(
+			req.ArgumentPosition,
+			fmt.Sprintf("Invalid value: expected a tuple of %d elements, but received %d elements.", len(v.ElementTypes), len(elems)),
+		)
+		return
+	}
+
+	for i, elem := range elems {
+		if _, err := tfconvert.Convert(ctx, elem, v.ElementTypes[i]); err != nil {
+			resp.Error = function.NewArgumentFuncError(
+				req.ArgumentPosition,
+				fmt.Sprintf("Invalid value: element %d must be of type %v: %v.", i, v.ElementTypes[i], err),
+			)
+			return
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package tfarg
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+func TestTupleValidator(t *testing.T) {
+	v := &TupleValidator{
+		ElementTypes: []attr.Type{basetypes.StringType{}, basetypes.NumberType{}},
+	}
+
+	tests := []struct {
+		name  string
+		given attr.Value
+		err   bool
+	}{
+		{
+			"Matching tuple",
+			basetypes.NewTupleValueMust(
+				[]attr.Type{basetypes.StringType{}, basetypes.NumberType{}},
+				[]attr.Value{basetypes.NewStringValue("a"), basetypes.NewNumberValue(big.NewFloat(1))},
+			),
+			false,
+		},
+		{
+			"Convertible list",
+			basetypes.NewListValueMust(
+				basetypes.StringType{},
+				[]attr.Value{basetypes.NewStringValue("a"), basetypes.NewStringValue("b")},
+			),
+			true,
+		},
+		{
+			"Convertible tuple",
+			basetypes.NewTupleValueMust(
+				[]attr.Type{basetypes.BoolType{}, basetypes.NumberType{}},
+				[]attr.Value{basetypes.NewBoolValue(true), basetypes.NewNumberValue(big.NewFloat(1))},
+			),
+			false,
+		},
+		{
+			"Wrong arity",
+			basetypes.NewTupleValueMust(
+				[]attr.Type{basetypes.StringType{}},
+				[]attr.Value{basetypes.NewStringValue("a")},
+			),
+			true,
+		},
+		{
+			"Wrong element type",
+			basetypes.NewTupleValueMust(
+				[]attr.Type{basetypes.StringType{}, basetypes.ListType{ElemType: basetypes.StringType{}}},
+				[]attr.Value{
+					basetypes.NewStringValue("a"),
+					basetypes.NewListValueMust(basetypes.StringType{}, []attr.Value{}),
+				},
+			),
+			true,
+		},
+		{
+			"Not a sequence",
+			basetypes.NewStringValue("a"),
+			true,
+		},
+		{
+			"Null",
+			basetypes.NewDynamicNull(),
+			false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			given, ok := test.given.(basetypes.DynamicValue)
+			if !ok {
+				given = basetypes.NewDynamicValue(test.given)
+			}
+
+			resp := &function.DynamicParameterValidatorResponse{}
+			v.ValidateParameterDynamic(context.Background(), function.DynamicParameterValidatorRequest{
+				ArgumentPosition: 1,
+				Value:            given,
+			}, resp)
+
+			if test.err && resp.Error == nil {
+				t.Errorf("validation was expected to fail")
+			}
+
+			if !test.err && resp.Error != nil {
+				t.Errorf("validation was not expected to fail: %v", resp.Error)
+			}
+
+			if resp.Error != nil && (resp.Error.FunctionArgument == nil || *resp.Error.FunctionArgument != 1) {
+				t.Errorf("error was expected to point to the argument")
+			}
+		})
+	}
+}
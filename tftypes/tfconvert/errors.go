@@ -0,0 +1,60 @@
+package tfconvert
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Error is returned when a value cannot be converted.
+//
+// It points to the element of the value where the conversion failed,
+// using the same notation Terraform uses for its values (e.g. `[0].name`).
+type Error struct {
+	// Path holds the steps from the root of the value to the element
+	// that failed to convert (e.g. `[0]`, `.name` or `["key"]`).
+	Path []string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if len(e.Path) == 0 {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("%s: %v", e.PathString(), e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// PathString returns the path to the element that failed to convert.
+func (e *Error) PathString() string {
+	return strings.Join(e.Path, "")
+}
+
+// atIndex points a conversion error to an element of a sequence.
+func atIndex(i int, err error) error {
+	return withStep(fmt.Sprintf("[%d]", i), err)
+}
+
+// atAttribute points a conversion error to an attribute of an object.
+func atAttribute(name string, err error) error {
+	return withStep("."+name, err)
+}
+
+// atKey points a conversion error to an element of a map.
+func atKey(key string, err error) error {
+	return withStep(fmt.Sprintf("[%q]", key), err)
+}
+
+// withStep prepends a step to the path of a conversion error.
+func withStep(step string, err error) error {
+	var cerr *Error
+	if errors.As(err, &cerr) {
+		return &Error{Path: append([]string{step}, cerr.Path...), Err: cerr.Err}
+	}
+
+	return &Error{Path: []string{step}, Err: err}
+}
@@ -0,0 +1,83 @@
+package tfconvert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"terraform-provider-func/tftypes"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// numberPrecision is the precision (in bits) Terraform uses for numbers.
+const numberPrecision = 512
+
+// decodeJSON decodes a JSON document into the value its structure implies:
+// arrays are tuples, objects are objects and nulls are dynamic nulls.
+//
+// The value can then be converted into the desired type.
+func decodeJSON(ctx context.Context, s string) (attr.Value, error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(s)))
+	dec.UseNumber()
+
+	var raw any
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	if dec.More() {
+		return nil, fmt.Errorf("invalid JSON: extraneous data after the value")
+	}
+
+	return jsonValue(ctx, raw)
+}
+
+// jsonValue builds the value implied by a decoded JSON value.
+func jsonValue(ctx context.Context, raw any) (attr.Value, error) {
+	switch v := raw.(type) {
+	case nil:
+		return basetypes.NewDynamicNull(), nil
+	case bool:
+		return basetypes.NewBoolValue(v), nil
+	case string:
+		return basetypes.NewStringValue(v), nil
+	case json.Number:
+		f, err := parseNumber(v.String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON number: %w", err)
+		}
+
+		return basetypes.NewNumberValue(f), nil
+	case []any:
+		tys := make([]attr.Type, len(v))
+		vals := make([]attr.Value, len(v))
+		for i, elem := range v {
+			val, err := jsonValue(ctx, elem)
+			if err != nil {
+				return nil, err
+			}
+
+			tys[i], vals[i] = val.Type(ctx), val
+		}
+
+		return tftypes.DiagnosticsToError(basetypes.NewTupleValue(tys, vals))
+	case map[string]any:
+		tys := make(map[string]attr.Type, len(v))
+		vals := make(map[string]attr.Value, len(v))
+		for k, elem := range v {
+			val, err := jsonValue(ctx, elem)
+			if err != nil {
+				return nil, err
+			}
+
+			tys[k], vals[k] = val.Type(ctx), val
+		}
+
+		return tftypes.DiagnosticsToError(basetypes.NewObjectValue(tys, vals))
+	}
+
+	return nil, fmt.Errorf("unexpected JSON value of type %T", raw)
+}
@@ -5,20 +5,59 @@ import (
 	"fmt"
 	"math"
 	"math/big"
+	"sort"
+	"strings"
 
 	"terraform-provider-func/tftypes"
 
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
-	"golang.org/x/exp/maps"
 )
 
 type ConverterFrom interface {
 	Convert(context.Context, attr.Type) (attr.Value, error)
 }
 
+// Options changes how values are converted.
+//
+// The zero value converts values strictly.
+type Options struct {
+	// DropExtraAttributes drops the attributes that are not part of the
+	// target object type, instead of failing the conversion.
+	DropExtraAttributes bool
+
+	// NullMissingAttributes sets the attributes of the target object type
+	// that are missing to null, instead of failing the conversion.
+	NullMissingAttributes bool
+}
+
 // Convert tries to convert a value to a given type.
+//
+// Null and unknown values (including the elements of collections, tuples and
+// objects) are converted into null and unknown values of the given type.
+//
+// If an element of a collection, tuple or object cannot be converted,
+// the returned error is an *Error that points to that element.
 func Convert(ctx context.Context, val attr.Value, typ attr.Type) (attr.Value, error) {
+	return ConvertWithOptions(ctx, val, typ, Options{})
+}
+
+// ConvertWithOptions is like Convert, but the conversion can be tuned
+// with options (e.g. to accept objects with extra attributes).
+func ConvertWithOptions(ctx context.Context, val attr.Value, typ attr.Type, opts Options) (attr.Value, error) {
+	// Dynamic values are converted based on their underlying value
+	if dv, ok := tftypes.EnsurePointer(val).(*basetypes.DynamicValue); ok && !tftypes.IsDynamicType(typ) {
+		if dv.IsNull() || dv.IsUnderlyingValueNull() {
+			return tftypes.NullValue(ctx, typ)
+		}
+
+		if dv.IsUnknown() || dv.IsUnderlyingValueUnknown() {
+			return tftypes.UnknownValue(ctx, typ)
+		}
+
+		val = dv.UnderlyingValue()
+	}
+
 	ty := val.Type(ctx)
 
 	// Same type, return the value
@@ -28,35 +67,147 @@ func Convert(ctx context.Context, val attr.Value, typ attr.Type) (attr.Value, er
 
 	// Convert into dynamic type
 	// Create a dynamic value wrapping the current value
-	if tftypes.TypeEqual(basetypes.DynamicType{}, typ) {
+	if tftypes.IsDynamicType(typ) {
 		return basetypes.NewDynamicValue(val), nil
 	}
 
+	// Null and unknown values have no content to convert
+	if val.IsNull() {
+		return tftypes.NullValue(ctx, typ)
+	}
+
+	if val.IsUnknown() {
+		return tftypes.UnknownValue(ctx, typ)
+	}
+
+	// Values of an assignable type only need their structure converted (e.g.
+	// a tuple into a list), the options only loosen the other conversions
+	if tftypes.TypeAssignable(ty, typ) {
+		opts = Options{}
+	}
+
 	// Anything else, convert them if possible
 	switch tftypes.PlainTypeString(ty) {
 	case "basetypes.BoolType":
-		return (&boolConverter{tftypes.EnsurePointer(val).(*basetypes.BoolValue)}).Convert(ctx, typ) //nolint:forcetypeassert
+		return (&boolConverter{tftypes.EnsurePointer(val).(*basetypes.BoolValue), opts}).Convert(ctx, typ) //nolint:forcetypeassert
 	case "basetypes.NumberType":
-		return (&numberConverter{tftypes.EnsurePointer(val).(*basetypes.NumberValue)}).Convert(ctx, typ) //nolint:forcetypeassert
+		return (&numberConverter{tftypes.EnsurePointer(val).(*basetypes.NumberValue), opts}).Convert(ctx, typ) //nolint:forcetypeassert
 	case "basetypes.StringType":
-		return (&stringConverter{tftypes.EnsurePointer(val).(*basetypes.StringValue)}).Convert(ctx, typ) //nolint:forcetypeassert
+		return (&stringConverter{tftypes.EnsurePointer(val).(*basetypes.StringValue), opts}).Convert(ctx, typ) //nolint:forcetypeassert
 	case "basetypes.TupleType":
-		return (&tupleConverter{tftypes.EnsurePointer(val).(*basetypes.TupleValue)}).Convert(ctx, typ) //nolint:forcetypeassert
+		return (&tupleConverter{tftypes.EnsurePointer(val).(*basetypes.TupleValue), opts}).Convert(ctx, typ) //nolint:forcetypeassert
 	case "basetypes.ListType":
-		return (&listConverter{tftypes.EnsurePointer(val).(*basetypes.ListValue)}).Convert(ctx, typ) //nolint:forcetypeassert
+		return (&listConverter{tftypes.EnsurePointer(val).(*basetypes.ListValue), opts}).Convert(ctx, typ) //nolint:forcetypeassert
 	case "basetypes.SetType":
-		return (&setConverter{tftypes.EnsurePointer(val).(*basetypes.SetValue)}).Convert(ctx, typ) //nolint:forcetypeassert
+		return (&setConverter{tftypes.EnsurePointer(val).(*basetypes.SetValue), opts}).Convert(ctx, typ) //nolint:forcetypeassert
 	case "basetypes.ObjectType":
-		return (&objectConverter{tftypes.EnsurePointer(val).(*basetypes.ObjectValue)}).Convert(ctx, typ) //nolint:forcetypeassert
+		return (&objectConverter{tftypes.EnsurePointer(val).(*basetypes.ObjectValue), opts}).Convert(ctx, typ) //nolint:forcetypeassert
 	case "basetypes.MapType":
-		return (&mapConverter{tftypes.EnsurePointer(val).(*basetypes.MapValue)}).Convert(ctx, typ) //nolint:forcetypeassert
+		return (&mapConverter{tftypes.EnsurePointer(val).(*basetypes.MapValue), opts}).Convert(ctx, typ) //nolint:forcetypeassert
 	}
 
 	return nil, fmt.Errorf("don't know how to convert %s into %s", ty, typ)
 }
 
+// convertElementsToTuple converts a sequence of elements (e.g. from a list)
+// into a tuple, converting each element to the tuple element type.
+func convertElementsToTuple(ctx context.Context, elems []attr.Value, typ attr.Type, opts Options) (attr.Value, error) {
+	target := tftypes.EnsureTypePointer(typ).(*basetypes.TupleType).ElementTypes() //nolint:forcetypeassert
+
+	if len(elems) != len(target) {
+		return nil, fmt.Errorf("cannot convert %d elements to a tuple of %d elements", len(elems), len(target))
+	}
+
+	convertedElements := make([]attr.Value, len(elems))
+	for i, elem := range elems {
+		val, err := ConvertWithOptions(ctx, elem, target[i], opts)
+		if err != nil {
+			return nil, atIndex(i, err)
+		}
+
+		convertedElements[i] = val
+	}
+
+	return tftypes.DiagnosticsToError(basetypes.NewTupleValue(target, convertedElements))
+}
+
+// convertElements converts a sequence of elements (e.g. from a tuple)
+// into elements of the same type, so they can be held by a collection.
+func convertElements(ctx context.Context, elems []attr.Value, typ attr.Type, opts Options) ([]attr.Value, error) {
+	convertedElements := make([]attr.Value, len(elems))
+	for i, elem := range elems {
+		val, err := ConvertWithOptions(ctx, elem, typ, opts)
+		if err != nil {
+			return nil, atIndex(i, err)
+		}
+
+		convertedElements[i] = val
+	}
+
+	return convertedElements, nil
+}
+
+// convertAttributesToObject converts a set of attributes (e.g. from a map)
+// into an object, converting each attribute to its attribute type.
+//
+// Attributes that are not part of the object type, and attributes of the
+// object type that are missing, fail the conversion unless the options say
+// otherwise.
+func convertAttributesToObject(ctx context.Context, attrs map[string]attr.Value, typ attr.Type, opts Options) (attr.Value, error) {
+	target := tftypes.EnsureTypePointer(typ).(*basetypes.ObjectType).AttributeTypes() //nolint:forcetypeassert
+
+	if !opts.DropExtraAttributes {
+		for _, name := range sortedKeys(attrs) {
+			if _, ok := target[name]; !ok {
+				return nil, atAttribute(name, fmt.Errorf("attribute is not part of %s", typ))
+			}
+		}
+	}
+
+	convertedAttributes := make(map[string]attr.Value, len(target))
+	for _, name := range sortedKeys(target) {
+		aty := target[name]
+
+		val, ok := attrs[name]
+		if !ok {
+			if !opts.NullMissingAttributes {
+				return nil, atAttribute(name, fmt.Errorf("attribute is required"))
+			}
+
+			null, err := tftypes.NullValue(ctx, aty)
+			if err != nil {
+				return nil, atAttribute(name, err)
+			}
+
+			convertedAttributes[name] = null
+			continue
+		}
+
+		converted, err := ConvertWithOptions(ctx, val, aty, opts)
+		if err != nil {
+			return nil, atAttribute(name, err)
+		}
+
+		convertedAttributes[name] = converted
+	}
+
+	return tftypes.DiagnosticsToError(basetypes.NewObjectValue(target, convertedAttributes))
+}
+
+// sortedKeys returns the keys of a map, sorted.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
 type boolConverter struct {
 	*basetypes.BoolValue
+	opts Options
 }
 
 func (v *boolConverter) Convert(ctx context.Context, typ attr.Type) (attr.Value, error) {
@@ -72,6 +223,7 @@ func (v *boolConverter) Convert(ctx context.Context, typ attr.Type) (attr.Value,
 
 type numberConverter struct {
 	*basetypes.NumberValue
+	opts Options
 }
 
 func (v *numberConverter) Convert(ctx context.Context, typ attr.Type) (attr.Value, error) {
@@ -84,7 +236,7 @@ func (v *numberConverter) Convert(ctx context.Context, typ attr.Type) (attr.Valu
 		rawFloat, acc := raw.Float64()
 		return basetypes.NewBoolValue(math.Abs(rawFloat) < math.Pow10(-int(acc))), nil
 	case "basetypes.StringType":
-		return basetypes.NewStringValue(v.ValueBigFloat().String()), nil
+		return basetypes.NewStringValue(v.ValueBigFloat().Text('f', -1)), nil
 	default:
 		return nil, fmt.Errorf("could not convert %v into %v", v.Type(ctx).String(), typ.String())
 	}
@@ -92,19 +244,78 @@ func (v *numberConverter) Convert(ctx context.Context, typ attr.Type) (attr.Valu
 
 type stringConverter struct {
 	*basetypes.StringValue
+	opts Options
 }
 
+// Convert converts a string following the same rules Terraform follows:
+// numbers are parsed as decimal numbers, and booleans must be either
+// "true" (or "1") or "false" (or "0"). Additionally, strings holding JSON
+// are decoded into structural types (lists, objects, etc.).
 func (v *stringConverter) Convert(ctx context.Context, typ attr.Type) (attr.Value, error) {
 	switch tftypes.PlainTypeString(typ) {
 	case "basetypes.StringType":
-		return basetypes.NewStringValue(v.String()), nil
+		return basetypes.NewStringValue(v.ValueString()), nil
+	case "basetypes.NumberType":
+		f, err := parseNumber(v.ValueString())
+		if err != nil {
+			return nil, err
+		}
+
+		return basetypes.NewNumberValue(f), nil
+	case "basetypes.BoolType":
+		b, err := parseBool(v.ValueString())
+		if err != nil {
+			return nil, err
+		}
+
+		return basetypes.NewBoolValue(b), nil
+	case "basetypes.TupleType", "basetypes.ListType", "basetypes.SetType", "basetypes.ObjectType", "basetypes.MapType":
+		val, err := decodeJSON(ctx, v.ValueString())
+		if err != nil {
+			return nil, fmt.Errorf("could not convert %v into %v: %w", v.Type(ctx).String(), typ.String(), err)
+		}
+
+		return ConvertWithOptions(ctx, val, typ, v.opts)
 	default:
 		return nil, fmt.Errorf("could not convert %v into %v", v.Type(ctx).String(), typ.String())
 	}
 }
 
+// parseNumber parses a decimal number with the precision of Terraform numbers.
+//
+// Like Terraform, it rejects infinities (e.g. "Inf"), which big.ParseFloat
+// accepts.
+func parseNumber(s string) (*big.Float, error) {
+	f, _, err := big.ParseFloat(s, 10, numberPrecision, big.ToNearestEven)
+	if err != nil || f.IsInf() {
+		return nil, fmt.Errorf("a number is required, got %q", s)
+	}
+
+	return f, nil
+}
+
+// parseBool parses a boolean the same way Terraform does.
+func parseBool(s string) (bool, error) {
+	switch s {
+	case "true", "1":
+		return true, nil
+	case "false", "0":
+		return false, nil
+	}
+
+	switch strings.ToLower(s) {
+	case "true":
+		return false, fmt.Errorf("a bool is required; to convert from string, use lowercase \"true\"")
+	case "false":
+		return false, fmt.Errorf("a bool is required; to convert from string, use lowercase \"false\"")
+	}
+
+	return false, fmt.Errorf("a bool is required, got %q", s)
+}
+
 type tupleConverter struct {
 	*basetypes.TupleValue
+	opts Options
 }
 
 func (v *tupleConverter) Convert(ctx context.Context, typ attr.Type) (attr.Value, error) {
@@ -112,12 +323,14 @@ func (v *tupleConverter) Convert(ctx context.Context, typ attr.Type) (attr.Value
 	case "basetypes.StringType":
 		return basetypes.NewStringValue(v.String()), nil
 	case "basetypes.ListType":
-		cty, err := tftypes.CollapseTypes(v.ElementTypes(ctx))
+		target := tftypes.EnsureTypePointer(typ).(*basetypes.ListType).ElementType() //nolint:forcetypeassert
+
+		convertedElements, err := convertElements(ctx, v.Elements(), target, v.opts)
 		if err != nil {
-			return nil, fmt.Errorf("cannot convert tuple to list: %w", err)
+			return nil, err
 		}
 
-		return tftypes.DiagnosticsToError(basetypes.NewListValue(cty, v.Elements()))
+		return tftypes.DiagnosticsToError(basetypes.NewListValue(target, convertedElements))
 	case "basetypes.TupleType":
 		target := tftypes.EnsureTypePointer(typ).(*basetypes.TupleType).ElementTypes() //nolint:forcetypeassert
 		current := v.ElementTypes(ctx)
@@ -128,7 +341,7 @@ func (v *tupleConverter) Convert(ctx context.Context, typ attr.Type) (attr.Value
 
 		hasDiff := false
 		for i := range current {
-			if !current[i].Equal(target[i]) {
+			if !tftypes.TypeEqual(current[i], target[i]) {
 				hasDiff = true
 				break
 			}
@@ -141,12 +354,12 @@ func (v *tupleConverter) Convert(ctx context.Context, typ attr.Type) (attr.Value
 		currentElements := v.Elements()
 		convertedElements := make([]attr.Value, len(target))
 		for i := range current {
-			if current[i].Equal(target[i]) {
+			if tftypes.TypeEqual(current[i], target[i]) {
 				convertedElements[i] = currentElements[i]
 			} else {
-				val, err := Convert(ctx, currentElements[i], target[i])
+				val, err := ConvertWithOptions(ctx, currentElements[i], target[i], v.opts)
 				if err != nil {
-					return nil, fmt.Errorf("cannot convert tuple index '%d': %v", i, err)
+					return nil, atIndex(i, err)
 				}
 
 				convertedElements[i] = val
@@ -155,12 +368,31 @@ func (v *tupleConverter) Convert(ctx context.Context, typ attr.Type) (attr.Value
 
 		return tftypes.DiagnosticsToError(basetypes.NewTupleValue(target, convertedElements))
 	case "basetypes.SetType":
-		cty, err := tftypes.CollapseTypes(v.ElementTypes(ctx))
+		target := tftypes.EnsureTypePointer(typ).(*basetypes.SetType).ElementType() //nolint:forcetypeassert
+
+		convertedElements, err := convertElements(ctx, v.Elements(), target, v.opts)
 		if err != nil {
-			return nil, fmt.Errorf("cannot convert tuple to set: %w", err)
+			return nil, err
+		}
+
+		return tftypes.DiagnosticsToError(basetypes.NewSetValue(target, convertedElements))
+	case "basetypes.ObjectType":
+		// Elements are assigned by position to the attributes sorted by name
+		names := sortedKeys(tftypes.EnsureTypePointer(typ).(*basetypes.ObjectType).AttributeTypes()) //nolint:forcetypeassert
+		elems := v.Elements()
+
+		if len(elems) > len(names) && !v.opts.DropExtraAttributes {
+			return nil, atIndex(len(names), fmt.Errorf("tuple has %d elements, but the object only has %d attributes", len(elems), len(names)))
 		}
 
-		return tftypes.DiagnosticsToError(basetypes.NewSetValue(cty, v.Elements()))
+		attrs := make(map[string]attr.Value, len(names))
+		for i, name := range names {
+			if i < len(elems) {
+				attrs[name] = elems[i]
+			}
+		}
+
+		return convertAttributesToObject(ctx, attrs, typ, v.opts)
 	default:
 		return nil, fmt.Errorf("could not convert %v into %v", v.Type(ctx).String(), typ.String())
 	}
@@ -168,6 +400,7 @@ func (v *tupleConverter) Convert(ctx context.Context, typ attr.Type) (attr.Value
 
 type listConverter struct {
 	*basetypes.ListValue
+	opts Options
 }
 
 func (v *listConverter) Convert(ctx context.Context, typ attr.Type) (attr.Value, error) {
@@ -176,28 +409,23 @@ func (v *listConverter) Convert(ctx context.Context, typ attr.Type) (attr.Value,
 		return basetypes.NewStringValue(v.String()), nil
 	case "basetypes.ListType":
 		target := tftypes.EnsureTypePointer(typ).(*basetypes.ListType).ElementType() //nolint:forcetypeassert
-		if v.ElementType(ctx).Equal(target) {
+		if tftypes.TypeEqual(v.ElementType(ctx), target) {
 			return v.ListValue, nil
 		}
 
 		convertedElements := make([]attr.Value, len(v.Elements()))
 		for i, value := range v.Elements() {
-			convertedValue, err := Convert(ctx, value, target)
+			convertedValue, err := ConvertWithOptions(ctx, value, target, v.opts)
 			if err != nil {
-				return nil, fmt.Errorf("cannot convert list index %d: %v", i, err)
+				return nil, atIndex(i, err)
 			}
 
 			convertedElements[i] = convertedValue
 		}
 
-		return tftypes.DiagnosticsToError(basetypes.NewListValue(typ, convertedElements))
+		return tftypes.DiagnosticsToError(basetypes.NewListValue(target, convertedElements))
 	case "basetypes.TupleType":
-		tys := make([]attr.Type, len(v.Elements()))
-		for i := range v.Elements() {
-			tys[i] = v.ElementType(ctx)
-		}
-
-		return tftypes.DiagnosticsToError(basetypes.NewTupleValue(tys, v.Elements()))
+		return convertElementsToTuple(ctx, v.Elements(), typ, v.opts)
 	default:
 		return nil, fmt.Errorf("could not convert %v into %v", v.Type(ctx).String(), typ.String())
 	}
@@ -205,6 +433,7 @@ func (v *listConverter) Convert(ctx context.Context, typ attr.Type) (attr.Value,
 
 type setConverter struct {
 	*basetypes.SetValue
+	opts Options
 }
 
 func (v *setConverter) Convert(ctx context.Context, typ attr.Type) (attr.Value, error) {
@@ -212,31 +441,33 @@ func (v *setConverter) Convert(ctx context.Context, typ attr.Type) (attr.Value,
 	case "basetypes.StringType":
 		return basetypes.NewStringValue(v.String()), nil
 	case "basetypes.ListType":
-		return tftypes.DiagnosticsToError(basetypes.NewListValue(v.ElementType(ctx), v.Elements()))
-	case "basetypes.TupleType":
-		tys := make([]attr.Type, len(v.Elements()))
-		for i := range v.Elements() {
-			tys[i] = v.ElementType(ctx)
+		target := tftypes.EnsureTypePointer(typ).(*basetypes.ListType).ElementType() //nolint:forcetypeassert
+
+		convertedElements, err := convertElements(ctx, v.Elements(), target, v.opts)
+		if err != nil {
+			return nil, err
 		}
 
-		return tftypes.DiagnosticsToError(basetypes.NewTupleValue(tys, v.Elements()))
+		return tftypes.DiagnosticsToError(basetypes.NewListValue(target, convertedElements))
+	case "basetypes.TupleType":
+		return convertElementsToTuple(ctx, v.Elements(), typ, v.opts)
 	case "basetypes.SetType":
 		target := tftypes.EnsureTypePointer(typ).(*basetypes.SetType).ElementType() //nolint:forcetypeassert
-		if v.ElementType(ctx).Equal(target) {
+		if tftypes.TypeEqual(v.ElementType(ctx), target) {
 			return v.SetValue, nil
 		}
 
 		convertedElements := make([]attr.Value, len(v.Elements()))
 		for i, value := range v.Elements() {
-			convertedValue, err := Convert(ctx, value, target)
+			convertedValue, err := ConvertWithOptions(ctx, value, target, v.opts)
 			if err != nil {
-				return nil, fmt.Errorf("cannot convert set index %d (value %s): %v", i, value.String(), err)
+				return nil, atIndex(i, err)
 			}
 
 			convertedElements[i] = convertedValue
 		}
 
-		return tftypes.DiagnosticsToError(basetypes.NewSetValue(typ, convertedElements))
+		return tftypes.DiagnosticsToError(basetypes.NewSetValue(target, convertedElements))
 	default:
 		return nil, fmt.Errorf("could not convert %v into %v", v.Type(ctx).String(), typ.String())
 	}
@@ -244,6 +475,7 @@ func (v *setConverter) Convert(ctx context.Context, typ attr.Type) (attr.Value,
 
 type objectConverter struct {
 	*basetypes.ObjectValue
+	opts Options
 }
 
 func (v *objectConverter) Convert(ctx context.Context, typ attr.Type) (attr.Value, error) {
@@ -251,60 +483,21 @@ func (v *objectConverter) Convert(ctx context.Context, typ attr.Type) (attr.Valu
 	case "basetypes.StringType":
 		return basetypes.NewStringValue(v.String()), nil
 	case "basetypes.ObjectType":
-		target := tftypes.EnsureTypePointer(typ).(*basetypes.ObjectType).AttributeTypes() //nolint:forcetypeassert
-		current := v.AttributeTypes(ctx)
-
-		if len(target) != len(current) {
-			return nil, fmt.Errorf("cannot convert to object type with fewer keys than the existing one")
-		}
-
-		for k := range current {
-			if _, ok := target[k]; !ok {
-				return nil, fmt.Errorf("target type is missing the '%s' key", k)
-			}
-		}
-
-		// No need to check if a target key is not in current because
-		// we already checked the length of the maps and at this point,
-		// they are equal, so if the length check passes and all the
-		// keys of current are in target, we can assume they both
-		// share the same keys.
-
-		hasDiff := false
-		for k := range current {
-			if !current[k].Equal(target[k]) {
-				hasDiff = true
-				break
-			}
-		}
-
-		if !hasDiff {
-			return v.ObjectValue, nil
-		}
-
-		currentAttributes := v.Attributes()
-		convertedAttributes := make(map[string]attr.Value, len(target))
-		for k := range current {
-			if current[k].Equal(target[k]) {
-				convertedAttributes[k] = currentAttributes[k]
-			} else {
-				val, err := Convert(ctx, currentAttributes[k], target[k])
-				if err != nil {
-					return nil, fmt.Errorf("cannot convert object key '%s': %v", k, err)
-				}
+		return convertAttributesToObject(ctx, v.Attributes(), typ, v.opts)
+	case "basetypes.MapType":
+		target := tftypes.EnsureTypePointer(typ).(*basetypes.MapType).ElementType() //nolint:forcetypeassert
 
-				convertedAttributes[k] = val
+		convertedElements := make(map[string]attr.Value, len(v.Attributes()))
+		for key, value := range v.Attributes() {
+			convertedValue, err := ConvertWithOptions(ctx, value, target, v.opts)
+			if err != nil {
+				return nil, atAttribute(key, err)
 			}
-		}
 
-		return tftypes.DiagnosticsToError(basetypes.NewObjectValue(target, convertedAttributes))
-	case "basetypes.MapType":
-		cty, err := tftypes.CollapseTypes(maps.Values(v.AttributeTypes(ctx)))
-		if err != nil {
-			return nil, fmt.Errorf("cannot convert object to map: %w", err)
+			convertedElements[key] = convertedValue
 		}
 
-		return tftypes.DiagnosticsToError(basetypes.NewMapValue(cty, v.Attributes()))
+		return tftypes.DiagnosticsToError(basetypes.NewMapValue(target, convertedElements))
 	default:
 		return nil, fmt.Errorf("could not convert %v into %v", v.Type(ctx).String(), typ.String())
 	}
@@ -312,6 +505,7 @@ func (v *objectConverter) Convert(ctx context.Context, typ attr.Type) (attr.Valu
 
 type mapConverter struct {
 	*basetypes.MapValue
+	opts Options
 }
 
 func (v *mapConverter) Convert(ctx context.Context, typ attr.Type) (attr.Value, error) {
@@ -319,29 +513,24 @@ func (v *mapConverter) Convert(ctx context.Context, typ attr.Type) (attr.Value,
 	case "basetypes.StringType":
 		return basetypes.NewStringValue(v.String()), nil
 	case "basetypes.ObjectType":
-		atys := make(map[string]attr.Type, len(v.Elements()))
-		for k := range v.Elements() {
-			atys[k] = v.ElementType(ctx)
-		}
-
-		return tftypes.DiagnosticsToError(basetypes.NewObjectValue(atys, v.Elements()))
+		return convertAttributesToObject(ctx, v.Elements(), typ, v.opts)
 	case "basetypes.MapType":
 		target := tftypes.EnsureTypePointer(typ).(*basetypes.MapType).ElementType() //nolint:forcetypeassert
-		if v.ElementType(ctx).Equal(target) {
+		if tftypes.TypeEqual(v.ElementType(ctx), target) {
 			return v.MapValue, nil
 		}
 
 		convertedElements := make(map[string]attr.Value, len(v.Elements()))
 		for key, value := range v.Elements() {
-			convertedValue, err := Convert(ctx, value, target)
+			convertedValue, err := ConvertWithOptions(ctx, value, target, v.opts)
 			if err != nil {
-				return nil, fmt.Errorf("cannot convert map key '%s': %v", key, err)
+				return nil, atKey(key, err)
 			}
 
 			convertedElements[key] = convertedValue
 		}
 
-		return tftypes.DiagnosticsToError(basetypes.NewMapValue(typ, convertedElements))
+		return tftypes.DiagnosticsToError(basetypes.NewMapValue(target, convertedElements))
 	default:
 		return nil, fmt.Errorf("could not convert %v into %v", v.Type(ctx).String(), typ.String())
 	}
@@ -0,0 +1,425 @@
+package tfconvert
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"terraform-provider-func/tftypes"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+func TestConvertErrorPath(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		given attr.Value
+		typ   attr.Type
+		path  string
+	}{
+		{
+			"Tuple element",
+			basetypes.NewTupleValueMust(
+				[]attr.Type{basetypes.NumberType{}, basetypes.ListType{ElemType: basetypes.StringType{}}},
+				[]attr.Value{
+					basetypes.NewNumberValue(big.NewFloat(1)),
+					basetypes.NewListValueMust(basetypes.StringType{}, []attr.Value{}),
+				},
+			),
+			basetypes.ListType{ElemType: basetypes.NumberType{}},
+			"[1]",
+		},
+		{
+			"Nested object attribute",
+			basetypes.NewListValueMust(
+				basetypes.ObjectType{AttrTypes: map[string]attr.Type{"a": basetypes.ListType{ElemType: basetypes.StringType{}}}},
+				[]attr.Value{
+					basetypes.NewObjectValueMust(
+						map[string]attr.Type{"a": basetypes.ListType{ElemType: basetypes.StringType{}}},
+						map[string]attr.Value{"a": basetypes.NewListValueMust(basetypes.StringType{}, []attr.Value{})},
+					),
+				},
+			),
+			basetypes.ListType{ElemType: basetypes.ObjectType{AttrTypes: map[string]attr.Type{"a": basetypes.BoolType{}}}},
+			"[0].a",
+		},
+		{
+			"Map element",
+			basetypes.NewMapValueMust(
+				basetypes.ListType{ElemType: basetypes.StringType{}},
+				map[string]attr.Value{"k": basetypes.NewListValueMust(basetypes.StringType{}, []attr.Value{})},
+			),
+			basetypes.MapType{ElemType: basetypes.NumberType{}},
+			`["k"]`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Convert(ctx, test.given, test.typ)
+
+			var cerr *Error
+			if !errors.As(err, &cerr) {
+				t.Fatalf("a conversion error was expected, got: %v", err)
+			}
+
+			if got := cerr.PathString(); got != test.path {
+				t.Errorf("wrong error path: want %q, got %q", test.path, got)
+			}
+		})
+	}
+}
+
+func TestConvertString(t *testing.T) {
+	ctx := context.Background()
+
+	precise, _, _ := big.ParseFloat("3.14159265358979323846264338327950288", 10, numberPrecision, big.ToNearestEven)
+
+	tests := []struct {
+		name  string
+		given string
+		typ   attr.Type
+		want  attr.Value
+		err   bool
+	}{
+		{"Number", "12.5", basetypes.NumberType{}, basetypes.NewNumberValue(big.NewFloat(12.5)), false},
+		{"Precise number", "3.14159265358979323846264338327950288", basetypes.NumberType{}, basetypes.NewNumberValue(precise), false},
+		{"Invalid number", "12a", basetypes.NumberType{}, nil, true},
+		{"Infinite number", "Inf", basetypes.NumberType{}, nil, true},
+		{"Negative infinite number", "-Inf", basetypes.NumberType{}, nil, true},
+		{"Signed infinite number", "+inf", basetypes.NumberType{}, nil, true},
+		{"True", "true", basetypes.BoolType{}, basetypes.NewBoolValue(true), false},
+		{"One", "1", basetypes.BoolType{}, basetypes.NewBoolValue(true), false},
+		{"False", "false", basetypes.BoolType{}, basetypes.NewBoolValue(false), false},
+		{"Zero", "0", basetypes.BoolType{}, basetypes.NewBoolValue(false), false},
+		{"Uppercase bool", "True", basetypes.BoolType{}, nil, true},
+		{"Invalid bool", "yes", basetypes.BoolType{}, nil, true},
+		{
+			"JSON list",
+			`[1, "2"]`,
+			basetypes.ListType{ElemType: basetypes.NumberType{}},
+			basetypes.NewListValueMust(basetypes.NumberType{}, []attr.Value{
+				basetypes.NewNumberValue(big.NewFloat(1)),
+				basetypes.NewNumberValue(big.NewFloat(2)),
+			}),
+			false,
+		},
+		{
+			"JSON object",
+			`{"name": "John", "age": 35}`,
+			basetypes.ObjectType{AttrTypes: map[string]attr.Type{"name": basetypes.StringType{}, "age": basetypes.NumberType{}}},
+			basetypes.NewObjectValueMust(
+				map[string]attr.Type{"name": basetypes.StringType{}, "age": basetypes.NumberType{}},
+				map[string]attr.Value{"name": basetypes.NewStringValue("John"), "age": basetypes.NewNumberValue(big.NewFloat(35))},
+			),
+			false,
+		},
+		{
+			"JSON map",
+			`{"a": true}`,
+			basetypes.MapType{ElemType: basetypes.StringType{}},
+			basetypes.NewMapValueMust(basetypes.StringType{}, map[string]attr.Value{"a": basetypes.NewStringValue("true")}),
+			false,
+		},
+		{"Invalid JSON", `[1,`, basetypes.ListType{ElemType: basetypes.NumberType{}}, nil, true},
+		{"Trailing JSON", `[1] [2]`, basetypes.ListType{ElemType: basetypes.NumberType{}}, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Convert(ctx, basetypes.NewStringValue(test.given), test.typ)
+
+			if test.err {
+				if err == nil {
+					t.Errorf("conversion was expected to fail, got: %v", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("conversion was not expected to fail: %v", err)
+			}
+
+			if !got.Equal(test.want) {
+				t.Errorf("wrong value:\nwant: %v\ngot : %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestConvertNumberToString(t *testing.T) {
+	f, _, _ := big.ParseFloat("12345678901234567890.5", 10, numberPrecision, big.ToNearestEven)
+
+	got, err := Convert(context.Background(), basetypes.NewNumberValue(f), basetypes.StringType{})
+	if err != nil {
+		t.Fatalf("conversion was not expected to fail: %v", err)
+	}
+
+	if want := basetypes.NewStringValue("12345678901234567890.5"); !got.Equal(want) {
+		t.Errorf("wrong value:\nwant: %v\ngot : %v", want, got)
+	}
+}
+
+func TestConvertNullAndUnknown(t *testing.T) {
+	ctx := context.Background()
+
+	types := map[string]attr.Type{
+		"bool":    basetypes.BoolType{},
+		"number":  basetypes.NumberType{},
+		"string":  basetypes.StringType{},
+		"tuple":   basetypes.TupleType{ElemTypes: []attr.Type{basetypes.StringType{}}},
+		"list":    basetypes.ListType{ElemType: basetypes.StringType{}},
+		"set":     basetypes.SetType{ElemType: basetypes.StringType{}},
+		"object":  basetypes.ObjectType{AttrTypes: map[string]attr.Type{"a": basetypes.StringType{}}},
+		"map":     basetypes.MapType{ElemType: basetypes.StringType{}},
+		"dynamic": basetypes.DynamicType{},
+	}
+
+	for fromName, from := range types {
+		for toName, to := range types {
+			t.Run(fromName+" to "+toName, func(t *testing.T) {
+				null, err := tftypes.NullValue(ctx, from)
+				if err != nil {
+					t.Fatalf("null value could not be created: %v", err)
+				}
+
+				got, err := Convert(ctx, null, to)
+				if err != nil {
+					t.Fatalf("null conversion was not expected to fail: %v", err)
+				}
+
+				if !got.IsNull() && !tftypes.IsDynamicType(to) {
+					t.Errorf("null conversion returned a known value: %v", got)
+				}
+
+				if !tftypes.TypeEqual(got.Type(ctx), to) {
+					t.Errorf("null conversion returned the wrong type: want %v, got %v", to, got.Type(ctx))
+				}
+
+				unknown, err := tftypes.UnknownValue(ctx, from)
+				if err != nil {
+					t.Fatalf("unknown value could not be created: %v", err)
+				}
+
+				got, err = Convert(ctx, unknown, to)
+				if err != nil {
+					t.Fatalf("unknown conversion was not expected to fail: %v", err)
+				}
+
+				if !got.IsUnknown() && !tftypes.IsDynamicType(to) {
+					t.Errorf("unknown conversion returned a known value: %v", got)
+				}
+
+				if !tftypes.TypeEqual(got.Type(ctx), to) {
+					t.Errorf("unknown conversion returned the wrong type: want %v, got %v", to, got.Type(ctx))
+				}
+			})
+		}
+	}
+}
+
+func TestConvertPartiallyUnknown(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		given attr.Value
+		typ   attr.Type
+		want  attr.Value
+	}{
+		{
+			"List with null element",
+			basetypes.NewListValueMust(basetypes.StringType{}, []attr.Value{
+				basetypes.NewStringValue("1"),
+				basetypes.NewStringNull(),
+			}),
+			basetypes.ListType{ElemType: basetypes.NumberType{}},
+			basetypes.NewListValueMust(basetypes.NumberType{}, []attr.Value{
+				basetypes.NewNumberValue(big.NewFloat(1)),
+				basetypes.NewNumberNull(),
+			}),
+		},
+		{
+			"Tuple with unknown element",
+			basetypes.NewTupleValueMust(
+				[]attr.Type{basetypes.BoolType{}, basetypes.StringType{}},
+				[]attr.Value{basetypes.NewBoolValue(true), basetypes.NewStringUnknown()},
+			),
+			basetypes.ListType{ElemType: basetypes.StringType{}},
+			basetypes.NewListValueMust(basetypes.StringType{}, []attr.Value{
+				basetypes.NewStringValue("true"),
+				basetypes.NewStringUnknown(),
+			}),
+		},
+		{
+			"Object with unknown attribute",
+			basetypes.NewObjectValueMust(
+				map[string]attr.Type{"a": basetypes.NumberType{}, "b": basetypes.DynamicType{}},
+				map[string]attr.Value{"a": basetypes.NewNumberUnknown(), "b": basetypes.NewDynamicNull()},
+			),
+			basetypes.ObjectType{AttrTypes: map[string]attr.Type{"a": basetypes.StringType{}, "b": basetypes.ListType{ElemType: basetypes.StringType{}}}},
+			basetypes.NewObjectValueMust(
+				map[string]attr.Type{"a": basetypes.StringType{}, "b": basetypes.ListType{ElemType: basetypes.StringType{}}},
+				map[string]attr.Value{"a": basetypes.NewStringUnknown(), "b": basetypes.NewListNull(basetypes.StringType{})},
+			),
+		},
+		{
+			"Dynamic wrapping null",
+			basetypes.NewDynamicValue(basetypes.NewStringNull()),
+			basetypes.NumberType{},
+			basetypes.NewNumberNull(),
+		},
+		{
+			"Dynamic wrapping unknown",
+			basetypes.NewDynamicValue(basetypes.NewStringUnknown()),
+			basetypes.SetType{ElemType: basetypes.NumberType{}},
+			basetypes.NewSetUnknown(basetypes.NumberType{}),
+		},
+		{
+			"JSON with null",
+			basetypes.NewStringValue(`{"a": null}`),
+			basetypes.MapType{ElemType: basetypes.NumberType{}},
+			basetypes.NewMapValueMust(basetypes.NumberType{}, map[string]attr.Value{"a": basetypes.NewNumberNull()}),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Convert(ctx, test.given, test.typ)
+			if err != nil {
+				t.Fatalf("conversion was not expected to fail: %v", err)
+			}
+
+			if !got.Equal(test.want) {
+				t.Errorf("wrong value:\nwant: %v\ngot : %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestConvertToObject(t *testing.T) {
+	ctx := context.Background()
+
+	person := basetypes.ObjectType{AttrTypes: map[string]attr.Type{
+		"age":  basetypes.NumberType{},
+		"name": basetypes.StringType{},
+	}}
+
+	john := basetypes.NewObjectValueMust(person.AttrTypes, map[string]attr.Value{
+		"age":  basetypes.NewNumberValue(big.NewFloat(35)),
+		"name": basetypes.NewStringValue("John"),
+	})
+
+	nameless := basetypes.NewObjectValueMust(person.AttrTypes, map[string]attr.Value{
+		"age":  basetypes.NewNumberValue(big.NewFloat(35)),
+		"name": basetypes.NewStringNull(),
+	})
+
+	extra := basetypes.NewObjectValueMust(
+		map[string]attr.Type{"age": basetypes.NumberType{}, "name": basetypes.StringType{}, "debug": basetypes.BoolType{}},
+		map[string]attr.Value{
+			"age":   basetypes.NewNumberValue(big.NewFloat(35)),
+			"name":  basetypes.NewStringValue("John"),
+			"debug": basetypes.NewBoolValue(true),
+		},
+	)
+
+	missing := basetypes.NewObjectValueMust(
+		map[string]attr.Type{"age": basetypes.StringType{}},
+		map[string]attr.Value{"age": basetypes.NewStringValue("35")},
+	)
+
+	tests := []struct {
+		name  string
+		given attr.Value
+		opts  Options
+		want  attr.Value
+		path  string
+	}{
+		{"Extra attributes fail", extra, Options{}, nil, ".debug"},
+		{"Extra attributes dropped", extra, Options{DropExtraAttributes: true}, john, ""},
+		{"Missing attributes fail", missing, Options{}, nil, ".name"},
+		{"Missing attributes set to null", missing, Options{NullMissingAttributes: true}, nameless, ""},
+		{
+			"Tuple by position",
+			basetypes.NewTupleValueMust(
+				[]attr.Type{basetypes.NumberType{}, basetypes.StringType{}},
+				[]attr.Value{basetypes.NewNumberValue(big.NewFloat(35)), basetypes.NewStringValue("John")},
+			),
+			Options{},
+			john,
+			"",
+		},
+		{
+			"Tuple with extra elements",
+			basetypes.NewTupleValueMust(
+				[]attr.Type{basetypes.NumberType{}, basetypes.StringType{}, basetypes.BoolType{}},
+				[]attr.Value{basetypes.NewNumberValue(big.NewFloat(35)), basetypes.NewStringValue("John"), basetypes.NewBoolValue(true)},
+			),
+			Options{},
+			nil,
+			"[2]",
+		},
+		{
+			"Short tuple",
+			basetypes.NewTupleValueMust(
+				[]attr.Type{basetypes.NumberType{}},
+				[]attr.Value{basetypes.NewNumberValue(big.NewFloat(35))},
+			),
+			Options{NullMissingAttributes: true},
+			nameless,
+			"",
+		},
+		{
+			"Map with known attributes",
+			basetypes.NewMapValueMust(basetypes.StringType{}, map[string]attr.Value{
+				"age":  basetypes.NewStringValue("35"),
+				"name": basetypes.NewStringValue("John"),
+			}),
+			Options{},
+			john,
+			"",
+		},
+		{
+			"Map with unknown keys",
+			basetypes.NewMapValueMust(basetypes.StringType{}, map[string]attr.Value{
+				"age":  basetypes.NewStringValue("35"),
+				"name": basetypes.NewStringValue("John"),
+				"zip":  basetypes.NewStringValue("00000"),
+			}),
+			Options{},
+			nil,
+			".zip",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ConvertWithOptions(ctx, test.given, person, test.opts)
+
+			if test.want == nil {
+				var cerr *Error
+				if !errors.As(err, &cerr) {
+					t.Fatalf("a conversion error was expected, got: %v (%v)", err, got)
+				}
+
+				if cerr.PathString() != test.path {
+					t.Errorf("wrong error path: want %q, got %q", test.path, cerr.PathString())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("conversion was not expected to fail: %v", err)
+			}
+
+			if !got.Equal(test.want) {
+				t.Errorf("wrong value:\nwant: %v\ngot : %v", test.want, got)
+			}
+		})
+	}
+}
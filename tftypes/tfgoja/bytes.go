@@ -0,0 +1,68 @@
+package tfgoja
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"terraform-provider-func/tftypes"
+
+	"github.com/dop251/goja"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// Terraform has no type for binary data, so binary data is passed
+// around as base64 encoded strings (like filebase64 or base64gzip do).
+var (
+	bytesType       = reflect.TypeOf([]byte(nil))
+	arrayBufferType = reflect.TypeOf(goja.ArrayBuffer{})
+)
+
+// BytesFromTfValue takes a Terraform string holding base64 encoded data and
+// returns the decoded data as a Uint8Array belonging to the given goja Runtime.
+//
+// Null values are converted into null. This function returns an error for
+// unknown values, values that are not strings and strings that are not
+// valid base64.
+func BytesFromTfValue(ctx context.Context, v attr.Value, js *goja.Runtime) (goja.Value, error) {
+	if dv, ok := tftypes.EnsurePointer(v).(*basetypes.DynamicValue); ok && !dv.IsNull() && !dv.IsUnknown() {
+		v = dv.UnderlyingValue()
+	}
+
+	switch {
+	case v.IsUnknown():
+		return nil, ErrUnknownValue
+	case v.IsNull():
+		return goja.Null(), nil
+	}
+
+	sv, ok := tftypes.EnsurePointer(v).(*basetypes.StringValue)
+	if !ok {
+		return nil, fmt.Errorf("%w: binary data must be a base64 encoded string, got %s", ErrUnknownType, v.Type(ctx))
+	}
+
+	data, err := base64.StdEncoding.DecodeString(sv.ValueString())
+	if err != nil {
+		return nil, fmt.Errorf("%w: binary data must be a base64 encoded string: %w", ErrConversionFailure, err)
+	}
+
+	return js.New(js.Get("Uint8Array"), js.ToValue(js.NewArrayBuffer(data)))
+}
+
+// toTfBytes converts a Uint8Array or an ArrayBuffer into a Terraform string
+// holding the base64 encoded data. It returns false for any other object.
+func toTfBytes(obj *goja.Object) (attr.Value, bool) {
+	var data []byte
+	switch obj.ExportType() {
+	case bytesType:
+		data, _ = obj.Export().([]byte)
+	case arrayBufferType:
+		buf, _ := obj.Export().(goja.ArrayBuffer)
+		data = buf.Bytes()
+	default:
+		return nil, false
+	}
+
+	return basetypes.NewStringValue(base64.StdEncoding.EncodeToString(data)), true
+}
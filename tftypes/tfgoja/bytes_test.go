@@ -0,0 +1,82 @@
+package tfgoja
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dop251/goja"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+func TestBytesFromTfValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		given attr.Value
+		test  string
+		err   bool
+	}{
+		{
+			name:  "Base64 string",
+			given: basetypes.NewStringValue("aGk="),
+			test:  `if (!(v instanceof Uint8Array) || v.join() !== '104,105') throw new Error('want Uint8Array [104, 105], but got '+v)`,
+		},
+		{
+			name:  "Empty string",
+			given: basetypes.NewStringValue(""),
+			test:  `if (!(v instanceof Uint8Array) || v.length !== 0) throw new Error('want an empty Uint8Array, but got '+v)`,
+		},
+		{
+			name:  "Dynamic string",
+			given: basetypes.NewDynamicValue(basetypes.NewStringValue("aGk=")),
+			test:  `if (!(v instanceof Uint8Array) || v.length !== 2) throw new Error('want Uint8Array [104, 105], but got '+v)`,
+		},
+		{
+			name:  "Null",
+			given: basetypes.NewStringNull(),
+			test:  `if (v !== null) throw new Error('want null, but got '+v)`,
+		},
+		{
+			name:  "Invalid base64",
+			given: basetypes.NewStringValue("not base64!"),
+			err:   true,
+		},
+		{
+			name:  "Not a string",
+			given: basetypes.NewBoolValue(true),
+			err:   true,
+		},
+		{
+			name:  "Unknown",
+			given: basetypes.NewStringUnknown(),
+			err:   true,
+		},
+	}
+
+	ctx := context.Background()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testJS := goja.New()
+
+			got, err := BytesFromTfValue(ctx, test.given, testJS)
+			if test.err {
+				if err == nil {
+					t.Errorf("conversion was expected to fail, but got %v", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("conversion errored: %s", err.Error())
+			}
+
+			if err := testJS.Set("v", got); err != nil {
+				t.Fatalf("could not set value: %s", err.Error())
+			}
+
+			if _, err := testJS.RunString(test.test); err != nil {
+				t.Errorf("assertion failed: %s", err.Error())
+			}
+		})
+	}
+}
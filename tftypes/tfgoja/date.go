@@ -0,0 +1,69 @@
+package tfgoja
+
+import (
+	"context"
+	"fmt"
+	"terraform-provider-func/tftypes"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// Terraform has no type for dates, so dates are passed around as RFC 3339
+// timestamps (like the timestamp function returns them).
+
+// DateFromTfValue takes a Terraform string holding an RFC 3339 timestamp and
+// returns the equivalent Date belonging to the given goja Runtime.
+//
+// Null values are converted into null. This function returns an error for
+// unknown values, values that are not strings and strings that are not
+// valid timestamps.
+func DateFromTfValue(ctx context.Context, v attr.Value, js *goja.Runtime) (goja.Value, error) {
+	if dv, ok := tftypes.EnsurePointer(v).(*basetypes.DynamicValue); ok && !dv.IsNull() && !dv.IsUnknown() {
+		v = dv.UnderlyingValue()
+	}
+
+	switch {
+	case v.IsUnknown():
+		return nil, ErrUnknownValue
+	case v.IsNull():
+		return goja.Null(), nil
+	}
+
+	sv, ok := tftypes.EnsurePointer(v).(*basetypes.StringValue)
+	if !ok {
+		return nil, fmt.Errorf("%w: dates must be RFC 3339 timestamps, got %s", ErrUnknownType, v.Type(ctx))
+	}
+
+	t, err := time.Parse(time.RFC3339, sv.ValueString())
+	if err != nil {
+		return nil, fmt.Errorf("%w: dates must be RFC 3339 timestamps: %w", ErrConversionFailure, err)
+	}
+
+	return js.New(js.Get("Date"), js.ToValue(t.UnixMilli()))
+}
+
+// toTfDate converts a Date into a Terraform string holding an RFC 3339
+// timestamp in UTC, with the precision given by the options.
+//
+// It returns false for any other object, and for invalid dates (which
+// are converted into null, like JSON.stringify does).
+func toTfDate(obj *goja.Object, opts Options) (attr.Value, bool) {
+	if obj.ClassName() != "Date" {
+		return nil, false
+	}
+
+	t, ok := obj.Export().(time.Time)
+	if !ok {
+		return nil, false
+	}
+
+	layout := time.RFC3339
+	if opts.Dates == DateMilliseconds {
+		layout = "2006-01-02T15:04:05.000Z07:00"
+	}
+
+	return basetypes.NewStringValue(t.UTC().Format(layout)), true
+}
@@ -0,0 +1,108 @@
+package tfgoja
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dop251/goja"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+func TestDateFromTfValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		given attr.Value
+		test  string
+		err   bool
+	}{
+		{
+			name:  "UTC timestamp",
+			given: basetypes.NewStringValue("2024-02-29T12:30:00Z"),
+			test:  `if (!(v instanceof Date) || v.toISOString() !== '2024-02-29T12:30:00.000Z') throw new Error('want 2024-02-29T12:30:00.000Z, but got '+v)`,
+		},
+		{
+			name:  "Timestamp with offset",
+			given: basetypes.NewStringValue("2024-02-29T14:30:00.250+02:00"),
+			test:  `if (v.toISOString() !== '2024-02-29T12:30:00.250Z') throw new Error('want 2024-02-29T12:30:00.250Z, but got '+v.toISOString())`,
+		},
+		{
+			name:  "Null",
+			given: basetypes.NewStringNull(),
+			test:  `if (v !== null) throw new Error('want null, but got '+v)`,
+		},
+		{
+			name:  "Date without time",
+			given: basetypes.NewStringValue("2024-02-29"),
+			err:   true,
+		},
+		{
+			name:  "Invalid date",
+			given: basetypes.NewStringValue("2024-02-30T00:00:00Z"),
+			err:   true,
+		},
+		{
+			name:  "Not a string",
+			given: basetypes.NewBoolValue(true),
+			err:   true,
+		},
+	}
+
+	ctx := context.Background()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testJS := goja.New()
+
+			got, err := DateFromTfValue(ctx, test.given, testJS)
+			if test.err {
+				if err == nil {
+					t.Errorf("conversion was expected to fail, but got %v", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("conversion errored: %s", err.Error())
+			}
+
+			if err := testJS.Set("v", got); err != nil {
+				t.Fatalf("could not set value: %s", err.Error())
+			}
+
+			if _, err := testJS.RunString(test.test); err != nil {
+				t.Errorf("assertion failed: %s", err.Error())
+			}
+		})
+	}
+}
+
+func TestToTfValueDates(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"Seconds", Options{}, "2024-02-29T12:30:00Z"},
+		{"Milliseconds", Options{Dates: DateMilliseconds}, "2024-02-29T12:30:00.250Z"},
+	}
+
+	ctx := context.Background()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			js := goja.New()
+			result, err := js.RunString(`new Date("2024-02-29T14:30:00.250+02:00")`)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			got, err := ToTfValueOfType(ctx, result, basetypes.StringType{}, js, test.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if want := basetypes.NewStringValue(test.want); !want.Equal(got) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+			}
+		})
+	}
+}
@@ -10,14 +10,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
+	"sort"
+	"strconv"
 	"terraform-provider-func/tftypes"
 
 	"github.com/dop251/goja"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"golang.org/x/exp/maps"
 )
 
+// maxSafeInteger is the largest integer a JavaScript number can hold exactly.
+const maxSafeInteger = 1<<53 - 1
+
 var (
 	ErrUnknownValue      = errors.New("cannot convert an unknown value")
 	ErrUnknownType       = errors.New("don't know how to convert type")
@@ -36,9 +43,23 @@ var (
 // back to attr.Value is lossy: maps will generalize as objects and lists and
 // sets will generalize as tuples.
 //
+// Numbers that a JavaScript number cannot hold exactly are converted as
+// described by NumberMode, and maps and sets as described by CollectionMode.
+//
 // This function must not be called concurrently with other use of the given
 // runtime.
 func FromTfValue(ctx context.Context, v attr.Value, js *goja.Runtime) (goja.Value, error) {
+	return FromTfValueWithOptions(ctx, v, js, Options{})
+}
+
+// FromTfValueWithOptions is like FromTfValue, but the conversion can be
+// tuned with options.
+func FromTfValueWithOptions(ctx context.Context, v attr.Value, js *goja.Runtime, opts Options) (goja.Value, error) {
+	// Dynamic values are converted based on their underlying value
+	if dv, ok := tftypes.EnsurePointer(v).(*basetypes.DynamicValue); ok && !dv.IsNull() && !dv.IsUnknown() {
+		v = dv.UnderlyingValue()
+	}
+
 	ty := v.Type(ctx)
 
 	switch {
@@ -46,10 +67,12 @@ func FromTfValue(ctx context.Context, v attr.Value, js *goja.Runtime) (goja.Valu
 		return nil, ErrUnknownValue
 	case v.IsNull():
 		return goja.Null(), nil
+	case opts.Collections == CollectionNative && (tftypes.IsMapType(ty) || tftypes.IsSetType(ty)):
+		return fromTfValueNative(ctx, v, js, opts)
 	case tftypes.IsObjectType(ty) || tftypes.IsMapType(ty):
-		return fromTfValueObject(ctx, v, js)
+		return fromTfValueObject(ctx, v, js, opts)
 	default:
-		raw, err := fromTfValueReflect(ctx, v, js)
+		raw, err := fromTfValueReflect(ctx, v, js, opts)
 		if err != nil {
 			return nil, err
 		}
@@ -57,27 +80,14 @@ func FromTfValue(ctx context.Context, v attr.Value, js *goja.Runtime) (goja.Valu
 	}
 }
 
-func fromTfValueReflect(ctx context.Context, v attr.Value, js *goja.Runtime) (any, error) {
+func fromTfValueReflect(ctx context.Context, v attr.Value, js *goja.Runtime, opts Options) (any, error) {
 	ty := v.Type(ctx)
 
 	switch tftypes.PlainTypeString(ty) {
-	case "basetypes.DynamicType":
-		return fromTfValueReflect(
-			ctx,
-			tftypes.EnsurePointer(
-				tftypes.EnsurePointer(v).(*basetypes.DynamicValue).UnderlyingValue(), //nolint:forcetypeassert
-			),
-			js,
-		)
 	case "basetypes.BoolType":
 		return tftypes.EnsurePointer(v).(*basetypes.BoolValue).ValueBool(), nil //nolint:forcetypeassert
 	case "basetypes.NumberType":
-		raw := tftypes.EnsurePointer(v).(*basetypes.NumberValue).ValueBigFloat() //nolint:forcetypeassert
-		if rawInt64, acc := raw.Int64(); acc == big.Exact {
-			return rawInt64, nil
-		}
-		rawFloat, _ := raw.Float64()
-		return rawFloat, nil
+		return fromTfNumber(tftypes.EnsurePointer(v).(*basetypes.NumberValue).ValueBigFloat(), opts), nil //nolint:forcetypeassert
 	case "basetypes.StringType":
 		return tftypes.EnsurePointer(v).(*basetypes.StringValue).ValueString(), nil //nolint:forcetypeassert
 	case "basetypes.TupleType":
@@ -85,7 +95,7 @@ func fromTfValueReflect(ctx context.Context, v attr.Value, js *goja.Runtime) (an
 
 		raw := make([]any, 0, len(vv.Elements()))
 		for i, el := range vv.Elements() {
-			gojaV, err := FromTfValue(ctx, el, js)
+			gojaV, err := FromTfValueWithOptions(ctx, el, js, opts)
 			if err != nil {
 				return nil, fmt.Errorf("%w: tuple[%d]: %w", ErrConversionFailure, i, err)
 			}
@@ -99,7 +109,7 @@ func fromTfValueReflect(ctx context.Context, v attr.Value, js *goja.Runtime) (an
 
 		raw := make([]any, 0, len(vv.Elements()))
 		for i, el := range vv.Elements() {
-			gojaV, err := FromTfValue(ctx, el, js)
+			gojaV, err := FromTfValueWithOptions(ctx, el, js, opts)
 			if err != nil {
 				return nil, fmt.Errorf("%w: list[%d]: %w", ErrConversionFailure, i, err)
 			}
@@ -113,7 +123,7 @@ func fromTfValueReflect(ctx context.Context, v attr.Value, js *goja.Runtime) (an
 
 		raw := make([]any, 0, len(vv.Elements()))
 		for i, el := range vv.Elements() {
-			gojaV, err := FromTfValue(ctx, el, js)
+			gojaV, err := FromTfValueWithOptions(ctx, el, js, opts)
 			if err != nil {
 				return nil, fmt.Errorf("%w: set[%d]: %w", ErrConversionFailure, i, err)
 			}
@@ -127,7 +137,7 @@ func fromTfValueReflect(ctx context.Context, v attr.Value, js *goja.Runtime) (an
 	return nil, fmt.Errorf("%w: %#v", ErrUnknownType, v)
 }
 
-func fromTfValueObject(ctx context.Context, v attr.Value, js *goja.Runtime) (*goja.Object, error) {
+func fromTfValueObject(ctx context.Context, v attr.Value, js *goja.Runtime, opts Options) (*goja.Object, error) {
 	ty := v.Type(ctx)
 
 	var attrs map[string]attr.Value
@@ -144,7 +154,7 @@ func fromTfValueObject(ctx context.Context, v attr.Value, js *goja.Runtime) (*go
 
 	ret := js.NewObject()
 	for k, v := range attrs {
-		gojaV, err := FromTfValue(ctx, v, js)
+		gojaV, err := FromTfValueWithOptions(ctx, v, js, opts)
 		if err != nil {
 			return nil, fmt.Errorf("%w: %s[%s]: %w", ErrConversionFailure, typ, k, err)
 		}
@@ -156,3 +166,93 @@ func fromTfValueObject(ctx context.Context, v attr.Value, js *goja.Runtime) (*go
 
 	return ret, nil
 }
+
+// fromTfValueNative converts a map into a JavaScript Map and a set into
+// a JavaScript Set.
+func fromTfValueNative(ctx context.Context, v attr.Value, js *goja.Runtime, opts Options) (*goja.Object, error) {
+	if vv, ok := tftypes.EnsurePointer(v).(*basetypes.SetValue); ok {
+		ret, err := js.New(js.Get("Set"))
+		if err != nil {
+			return nil, err
+		}
+
+		add, _ := goja.AssertFunction(ret.Get("add"))
+		for i, el := range vv.Elements() {
+			gojaV, err := FromTfValueWithOptions(ctx, el, js, opts)
+			if err != nil {
+				return nil, fmt.Errorf("%w: set[%d]: %w", ErrConversionFailure, i, err)
+			}
+
+			if _, err := add(ret, gojaV); err != nil {
+				return nil, err
+			}
+		}
+
+		return ret, nil
+	}
+
+	vv, ok := tftypes.EnsurePointer(v).(*basetypes.MapValue)
+	if !ok {
+		return nil, fmt.Errorf("%w: '%v' should be map or set, but it is not", ErrUnknownType, v.Type(ctx))
+	}
+
+	ret, err := js.New(js.Get("Map"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Maps keep the insertion order, which is made predictable
+	elems := vv.Elements()
+	keys := maps.Keys(elems)
+	sort.Strings(keys)
+
+	set, _ := goja.AssertFunction(ret.Get("set"))
+	for _, k := range keys {
+		gojaV, err := FromTfValueWithOptions(ctx, elems[k], js, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%w: map[%s]: %w", ErrConversionFailure, k, err)
+		}
+
+		if _, err := set(ret, js.ToValue(k), gojaV); err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+// fromTfNumber converts a Terraform number into a JavaScript number,
+// unless a JavaScript number cannot hold it exactly.
+func fromTfNumber(f *big.Float, opts Options) any {
+	if f.IsInt() {
+		if i, acc := f.Int64(); acc == big.Exact && i >= -maxSafeInteger && i <= maxSafeInteger {
+			return i
+		}
+
+		if opts.Numbers == NumberString {
+			return f.Text('f', -1)
+		}
+
+		i, _ := f.Int(nil)
+		return i
+	}
+
+	raw, acc := f.Float64()
+	if acc == big.Exact || math.IsInf(raw, 0) {
+		return raw
+	}
+
+	// Decimal numbers (e.g. 0.1) are stored with more precision than
+	// a float64, but they are still the same number if the shortest
+	// representation of the float64 is the number itself.
+	shortest, _, err := big.ParseFloat(strconv.FormatFloat(raw, 'g', -1, 64), 10, f.Prec(), big.ToNearestEven)
+	if err == nil && shortest.Cmp(f) == 0 {
+		return raw
+	}
+
+	if opts.Numbers == NumberString {
+		return f.Text('f', -1)
+	}
+
+	return raw
+}
@@ -187,3 +187,154 @@ func TestFromTfValue(t *testing.T) {
 		})
 	}
 }
+
+func TestFromTfValueNumbers(t *testing.T) {
+	parse := func(s string) *big.Float {
+		f, _, err := big.ParseFloat(s, 10, 512, big.ToNearestEven)
+		if err != nil {
+			t.Fatalf("invalid number %s: %v", s, err)
+		}
+		return f
+	}
+
+	tests := []struct {
+		name  string
+		given *big.Float
+		opts  Options
+		test  string
+	}{
+		{
+			"Safe integer",
+			parse("9007199254740991"),
+			Options{},
+			`if (v !== 9007199254740991) throw new Error('want 9007199254740991, but got '+v)`,
+		},
+		{
+			"Large integer",
+			parse("123456789012345678901234567890"),
+			Options{},
+			`if (v !== 123456789012345678901234567890n) throw new Error('want 123456789012345678901234567890n, but got '+v)`,
+		},
+		{
+			"Large negative integer",
+			parse("-9007199254740993"),
+			Options{},
+			`if (v !== -9007199254740993n) throw new Error('want -9007199254740993n, but got '+v)`,
+		},
+		{
+			"Decimal",
+			parse("0.1"),
+			Options{},
+			`if (v !== 0.1) throw new Error('want 0.1, but got '+v)`,
+		},
+		{
+			"Imprecise decimal",
+			parse("0.1000000000000000000001"),
+			Options{},
+			`if (v !== 0.1) throw new Error('want 0.1, but got '+v)`,
+		},
+		{
+			"Large integer as string",
+			parse("123456789012345678901234567890"),
+			Options{Numbers: NumberString},
+			`if (v !== '123456789012345678901234567890') throw new Error('want "123456789012345678901234567890", but got '+v)`,
+		},
+		{
+			"Imprecise decimal as string",
+			parse("0.1000000000000000000001"),
+			Options{Numbers: NumberString},
+			`if (v !== '0.1000000000000000000001') throw new Error('want "0.1000000000000000000001", but got '+v)`,
+		},
+		{
+			"Decimal with string mode",
+			parse("0.1"),
+			Options{Numbers: NumberString},
+			`if (v !== 0.1) throw new Error('want 0.1, but got '+v)`,
+		},
+	}
+
+	ctx := context.Background()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testJS := goja.New()
+
+			got, err := FromTfValueWithOptions(ctx, basetypes.NewNumberValue(test.given), testJS, test.opts)
+			if err != nil {
+				t.Fatalf("conversion errored: %s", err.Error())
+			}
+
+			if err := testJS.Set("v", got); err != nil {
+				t.Fatalf("could not set value: %s", err.Error())
+			}
+
+			if _, err := testJS.RunString(test.test); err != nil {
+				t.Errorf("assertion failed: %s", err.Error())
+			}
+		})
+	}
+}
+
+func TestFromTfValueNativeCollections(t *testing.T) {
+	tests := []struct {
+		name  string
+		given attr.Value
+		opts  Options
+		test  string
+	}{
+		{
+			"Map",
+			basetypes.NewMapValueMust(basetypes.StringType{}, map[string]attr.Value{
+				"b": basetypes.NewStringValue("2"),
+				"a": basetypes.NewStringValue("1"),
+			}),
+			Options{Collections: CollectionNative},
+			`if (!(v instanceof Map) || [...v.keys()].join() !== 'a,b' || v.get('b') !== '2') throw new Error('want Map {a, b}, but got '+v)`,
+		},
+		{
+			"Set",
+			basetypes.NewSetValueMust(basetypes.NumberType{}, []attr.Value{
+				basetypes.NewNumberValue(big.NewFloat(1)),
+				basetypes.NewNumberValue(big.NewFloat(2)),
+			}),
+			Options{Collections: CollectionNative},
+			`if (!(v instanceof Set) || v.size !== 2 || !v.has(1) || !v.has(2)) throw new Error('want Set {1, 2}, but got '+v)`,
+		},
+		{
+			"Object",
+			basetypes.NewObjectValueMust(
+				map[string]attr.Type{"a": basetypes.StringType{}},
+				map[string]attr.Value{"a": basetypes.NewStringValue("1")},
+			),
+			Options{Collections: CollectionNative},
+			`if (v instanceof Map || v.a !== '1') throw new Error('want {a: "1"}, but got '+v)`,
+		},
+		{
+			"Plain map",
+			basetypes.NewMapValueMust(basetypes.StringType{}, map[string]attr.Value{
+				"a": basetypes.NewStringValue("1"),
+			}),
+			Options{},
+			`if (v instanceof Map || v.a !== '1') throw new Error('want {a: "1"}, but got '+v)`,
+		},
+	}
+
+	ctx := context.Background()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testJS := goja.New()
+
+			got, err := FromTfValueWithOptions(ctx, test.given, testJS, test.opts)
+			if err != nil {
+				t.Fatalf("conversion errored: %s", err.Error())
+			}
+
+			if err := testJS.Set("v", got); err != nil {
+				t.Fatalf("could not set value: %s", err.Error())
+			}
+
+			if _, err := testJS.RunString(test.test); err != nil {
+				t.Errorf("assertion failed: %s", err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package tfgoja
+
+// NumberMode defines how Terraform numbers that a JavaScript number
+// cannot hold exactly (e.g. 64-bit identifiers) are passed to JavaScript.
+type NumberMode int
+
+const (
+	// NumberBigInt passes integers as BigInt values. Decimal numbers are
+	// approximated to the closest JavaScript number.
+	NumberBigInt NumberMode = iota
+
+	// NumberString passes numbers as decimal strings (e.g. "0.1"), which can
+	// be handled by any decimal library.
+	NumberString
+)
+
+// CollectionMode defines how Terraform maps and sets are passed to JavaScript.
+type CollectionMode int
+
+const (
+	// CollectionPlain passes maps as plain objects and sets as arrays, the
+	// same way they are represented in JSON.
+	CollectionPlain CollectionMode = iota
+
+	// CollectionNative passes maps as Map instances and sets as Set
+	// instances, so that they can be told apart from objects and lists.
+	CollectionNative
+)
+
+// DatePrecision defines the precision of the timestamps Date values
+// are converted into.
+type DatePrecision int
+
+const (
+	// DateSeconds formats dates like Terraform's timestamp function
+	// (e.g. "2006-01-02T15:04:05Z").
+	DateSeconds DatePrecision = iota
+
+	// DateMilliseconds keeps the milliseconds of the dates
+	// (e.g. "2006-01-02T15:04:05.000Z").
+	DateMilliseconds
+)
+
+// Options changes how values are converted between Terraform and JavaScript.
+//
+// The zero value gives the default conversions.
+type Options struct {
+	Numbers     NumberMode
+	Collections CollectionMode
+	Dates       DatePrecision
+}
@@ -2,18 +2,17 @@ package tfgoja
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"time"
+	"math"
+	"math/big"
+	"strconv"
 
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
+	"terraform-provider-func/tftypes"
 
 	"github.com/dop251/goja"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
-	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
-	dynamicstruct "github.com/ompluscator/dynamic-struct"
+	"golang.org/x/exp/maps"
 )
 
 // ToTfValue attempts to find an attr.Value that is equivalent to the given
@@ -24,82 +23,362 @@ import (
 // includes many types that have no equivalent in Terraform, such as functions.
 //
 // For predictability and consistency, the conversion from JavaScript to Terraform
-// is defined as a conversion from JavaScript to JSON using the same rules
-// as JavaScript's JSON.stringify function, followed by interpretation of that
-// result in Terraform using the same rules as the terraform/json package follows.
+// follows the same rules as JavaScript's JSON.stringify function, followed by
+// interpretation of that result in Terraform using the same rules as the
+// terraform/json package follows. The exceptions are Date values, which are
+// formatted like Terraform's timestamp function formats them, BigInt values, which
+// JSON.stringify rejects, but are converted into exact Terraform numbers, and
+// Map and Set instances, which are converted into Terraform maps and sets
+// (instead of empty objects) when all their values have the same type, and
+// Uint8Array and ArrayBuffer instances, which are converted into base64
+// encoded strings.
 //
-// This function therefore fails in the cases where JSON.stringify would fail.
-// Because neither Terraform nor JSON have an equivalent of "undefined", in cases
-// where JSON.stringify would return undefined ToTfValue returns a Terraform
-// null value.
+// This function therefore fails in the cases where JSON.stringify would fail
+// (e.g. cyclic values). Because neither Terraform nor JSON have an equivalent of
+// "undefined", in cases where JSON.stringify would return undefined ToTfValue
+// returns a Terraform null value.
 func ToTfValue(ctx context.Context, v goja.Value, js *goja.Runtime) (attr.Value, error) {
-	// There are some exceptions for things that can't be turned into a
-	// goja.Object, because they don't have associated boxing prototypes.
-	if goja.IsNull(v) || goja.IsUndefined(v) {
-		return basetypes.NewDynamicNull(), nil
+	return ToTfValueOfType(ctx, v, basetypes.DynamicType{}, js, Options{})
+}
+
+// ToTfValueOfType works like ToTfValue, but the conversion is guided by the
+// type the value is expected to have: arrays become lists or sets and objects
+// become maps when the type asks for them. The types are only inferred from
+// the value where the expected type is dynamic.
+//
+// The result is not guaranteed to be of the given type: the parts of the value
+// that do not match it keep the type implied by their structure, so that the
+// caller can convert them (or report a meaningful error).
+//
+// Dates are converted as described by DatePrecision.
+func ToTfValueOfType(ctx context.Context, v goja.Value, typ attr.Type, js *goja.Runtime, opts Options) (attr.Value, error) {
+	return toTfValue(ctx, v, typ, js, opts, nil)
+}
+
+// toTfValue converts a goja.Value into an attr.Value, using the given type
+// to pick the kind of collection the arrays and objects are converted into.
+//
+// The objects that are being converted are tracked to detect cycles.
+func toTfValue(ctx context.Context, v goja.Value, typ attr.Type, js *goja.Runtime, opts Options, parents []*goja.Object) (attr.Value, error) {
+	if obj, ok := v.(*goja.Object); ok {
+		// Dates are formatted like Terraform formats timestamps
+		if val, ok := toTfDate(obj, opts); ok {
+			return val, nil
+		}
+
+		// Objects can customize their JSON representation
+		if toJSON, ok := goja.AssertFunction(obj.Get("toJSON")); ok {
+			res, err := toJSON(obj, js.ToValue(""))
+			if err != nil {
+				return nil, fmt.Errorf("%w: toJSON: %w", ErrConversionFailure, err)
+			}
+
+			v = res
+		}
 	}
 
-	// For now at least, the implementation is literally to go via JSON
-	// encoding, because goja offers a convenient interface to the same
-	// behavior as JSON.stringify.
-	src, err := v.ToObject(js).MarshalJSON()
-	if err != nil {
-		return basetypes.NewDynamicNull(), err
+	if isJSONUndefined(v) || goja.IsNull(v) {
+		return nullOfType(ctx, typ)
 	}
 
-	// If the object cannot be serialized, it will be printed as "null"
-	// so we need to return null
-	if string(src) == "null" {
-		return basetypes.NewDynamicNull(), err
+	obj, ok := v.(*goja.Object)
+	if !ok {
+		return toTfPrimitive(ctx, v.Export(), typ)
 	}
 
-	ty, err := JSONImpliedType(src)
+	switch obj.ClassName() {
+	case "Number", "String", "Boolean", "BigInt":
+		// Primitive wrappers are serialized as the primitive they hold
+		return toTfPrimitive(ctx, obj.Export(), typ)
+	}
+
+	// Binary data is serialized as base64 (instead of an object keyed by index)
+	if val, ok := toTfBytes(obj); ok {
+		return val, nil
+	}
+
+	for _, parent := range parents {
+		if parent.SameAs(obj) {
+			return nil, fmt.Errorf("%w: cyclic value", ErrConversionFailure)
+		}
+	}
+	parents = append(parents[:len(parents):len(parents)], obj)
+
+	switch {
+	case obj.ClassName() == "Array":
+		length := int(obj.Get("length").ToInteger())
+
+		elems := make([]goja.Value, length)
+		for i := range elems {
+			elems[i] = obj.Get(strconv.Itoa(i))
+		}
+
+		return toTfSequence(ctx, elems, typ, js, opts, parents, false)
+	case isInstanceOf(obj, "Set", js):
+		elems, err := arrayFrom(obj, js)
+		if err != nil {
+			return nil, err
+		}
+
+		return toTfSequence(ctx, elems, typ, js, opts, parents, true)
+	case isInstanceOf(obj, "Map", js):
+		pairs, err := arrayFrom(obj, js)
+		if err != nil {
+			return nil, err
+		}
+
+		entries := make([]entry, len(pairs))
+		for i, pair := range pairs {
+			kv := pair.ToObject(js)
+
+			key := kv.Get("0")
+			if _, ok := key.Export().(string); !ok {
+				return nil, fmt.Errorf("%w: map keys must be strings, got %s", ErrConversionFailure, key.String())
+			}
+
+			entries[i] = entry{key: key.String(), value: kv.Get("1")}
+		}
+
+		return toTfAttributes(ctx, entries, typ, js, opts, parents, true)
+	}
+
+	entries := make([]entry, 0)
+	for _, k := range obj.Keys() {
+		entries = append(entries, entry{key: k, value: obj.Get(k)})
+	}
+
+	return toTfAttributes(ctx, entries, typ, js, opts, parents, false)
+}
+
+// entry is a key-value pair of a JavaScript object or Map.
+type entry struct {
+	key   string
+	value goja.Value
+}
+
+// isInstanceOf checks if an object is an instance of the given global class.
+func isInstanceOf(obj *goja.Object, class string, js *goja.Runtime) bool {
+	ctor, ok := js.Get(class).(*goja.Object)
+	return ok && js.InstanceOf(obj, ctor)
+}
+
+// arrayFrom returns the values of an iterable object (e.g. a Map or a Set).
+func arrayFrom(obj *goja.Object, js *goja.Runtime) ([]goja.Value, error) {
+	from, _ := goja.AssertFunction(js.Get("Array").ToObject(js).Get("from"))
+
+	res, err := from(goja.Undefined(), obj)
 	if err != nil {
-		// It'd be weird to end up here because that would suggest that
-		// goja's MarshalJSON produced an invalid result, but we'll return
-		// it out anyway.
-		return basetypes.NewDynamicNull(), err
+		return nil, fmt.Errorf("%w: %w", ErrConversionFailure, err)
 	}
 
-	var value any = v.Export()
+	var elems []goja.Value
+	if err := js.ExportTo(res, &elems); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConversionFailure, err)
+	}
+
+	return elems, nil
+}
 
-	if vt, ok := value.(time.Time); ok {
-		value = vt.UTC().Format("2006-01-02T15:04:05.000Z")
+// toTfSequence converts the elements of a JavaScript array into a list or
+// a set when the given type is one, and into a tuple otherwise.
+//
+// The elements of a JavaScript Set (native) are converted into a set even
+// if the type is dynamic, as long as they are all of the same type.
+func toTfSequence(ctx context.Context, elems []goja.Value, typ attr.Type, js *goja.Runtime, opts Options, parents []*goja.Object, native bool) (attr.Value, error) {
+	elemType := func(int) attr.Type { return basetypes.DynamicType{} }
+	switch t := typ.(type) {
+	case attr.TypeWithElementType:
+		elemType = func(int) attr.Type { return t.ElementType() }
+	case attr.TypeWithElementTypes:
+		elemTypes := t.ElementTypes()
+		elemType = func(i int) attr.Type {
+			if i < len(elemTypes) {
+				return elemTypes[i]
+			}
+
+			return basetypes.DynamicType{}
+		}
 	}
 
-	if _, ok := ty.(basetypes.ObjectType); ok {
-		obj, ok := value.(map[string]any)
-		if !ok {
-			return nil, fmt.Errorf("exported object is not a map, but %T", value)
+	tys := make([]attr.Type, len(elems))
+	vals := make([]attr.Value, len(elems))
+	for i, elem := range elems {
+		val, err := toTfValue(ctx, elem, elemType(i), js, opts, parents)
+		if err != nil {
+			return nil, fmt.Errorf("%w: [%d]: %w", ErrConversionFailure, i, err)
 		}
 
-		builder := dynamicstruct.NewStruct()
+		tys[i], vals[i] = val.Type(ctx), val
+	}
 
-		for k, v := range obj {
-			builder.AddField(
-				// We need to title the key to comply with GoLang struct exporting
-				cases.Title(language.English, cases.Compact).String(k),
-				v,
-				// We add tags to make sure other systems parse the key as it is
-				fmt.Sprintf(`tfsdk:"%s" json:"%s"`, k, k),
-			)
+	if t, ok := typ.(attr.TypeWithElementType); ok && allOfType(tys, t.ElementType()) {
+		switch {
+		case tftypes.IsListType(typ):
+			return tftypes.DiagnosticsToError(basetypes.NewListValue(t.ElementType(), vals))
+		case tftypes.IsSetType(typ):
+			return tftypes.DiagnosticsToError(basetypes.NewSetValue(t.ElementType(), vals))
 		}
+	}
 
-		value = builder.Build().New()
+	if native && (typ == nil || tftypes.IsDynamicType(typ)) {
+		if ety, ok := impliedElementType(ctx, vals); ok {
+			for i, val := range vals {
+				vals[i] = typeNull(ctx, val, ety)
+			}
 
-		if err := json.Unmarshal(src, &value); err != nil {
-			return nil, err
+			return tftypes.DiagnosticsToError(basetypes.NewSetValue(ety, vals))
 		}
 	}
 
-	var res attr.Value
-	if diags := tfsdk.ValueFrom(ctx, value, ty, &res); diags.HasError() {
-		var err error = fmt.Errorf("could not reflect goja value into tf")
-		for _, diag := range diags {
-			err = fmt.Errorf("%v: %v", err, diag.Detail())
+	return tftypes.DiagnosticsToError(basetypes.NewTupleValue(tys, vals))
+}
+
+// toTfAttributes converts the entries of a JavaScript object into a map
+// when the given type is one, and into an object otherwise.
+//
+// The entries of a JavaScript Map (native) are converted into a map even
+// if the type is dynamic, as long as they are all of the same type.
+func toTfAttributes(ctx context.Context, entries []entry, typ attr.Type, js *goja.Runtime, opts Options, parents []*goja.Object, native bool) (attr.Value, error) {
+	attrType := func(string) attr.Type { return basetypes.DynamicType{} }
+	switch t := typ.(type) {
+	case attr.TypeWithElementType:
+		attrType = func(string) attr.Type { return t.ElementType() }
+	case attr.TypeWithAttributeTypes:
+		attrTypes := t.AttributeTypes()
+		attrType = func(k string) attr.Type {
+			if ty, ok := attrTypes[k]; ok {
+				return ty
+			}
+
+			return basetypes.DynamicType{}
+		}
+	}
+
+	atys := make(map[string]attr.Type)
+	attrs := make(map[string]attr.Value)
+	for _, e := range entries {
+		// Properties that JSON cannot represent are left out
+		if isJSONUndefined(e.value) {
+			continue
+		}
+
+		val, err := toTfValue(ctx, e.value, attrType(e.key), js, opts, parents)
+		if err != nil {
+			return nil, fmt.Errorf("%w: .%s: %w", ErrConversionFailure, e.key, err)
+		}
+
+		atys[e.key], attrs[e.key] = val.Type(ctx), val
+	}
+
+	if t, ok := typ.(attr.TypeWithElementType); ok && tftypes.IsMapType(typ) && allOfType(maps.Values(atys), t.ElementType()) {
+		return tftypes.DiagnosticsToError(basetypes.NewMapValue(t.ElementType(), attrs))
+	}
+
+	if native && (typ == nil || tftypes.IsDynamicType(typ)) {
+		if ety, ok := impliedElementType(ctx, maps.Values(attrs)); ok {
+			for k, val := range attrs {
+				attrs[k] = typeNull(ctx, val, ety)
+			}
+
+			return tftypes.DiagnosticsToError(basetypes.NewMapValue(ety, attrs))
+		}
+	}
+
+	return tftypes.DiagnosticsToError(basetypes.NewObjectValue(atys, attrs))
+}
+
+// impliedElementType returns the type shared by all the non-null values,
+// if there is one.
+func impliedElementType(ctx context.Context, vals []attr.Value) (attr.Type, bool) {
+	var ety attr.Type
+	for _, val := range vals {
+		if val.IsNull() && tftypes.IsDynamicType(val.Type(ctx)) {
+			continue
+		}
+
+		if ety == nil {
+			ety = val.Type(ctx)
+		} else if !tftypes.TypeEqual(ety, val.Type(ctx)) {
+			return nil, false
+		}
+	}
+
+	return ety, ety != nil
+}
+
+// typeNull replaces a dynamic null value with a null value of the given type.
+func typeNull(ctx context.Context, val attr.Value, typ attr.Type) attr.Value {
+	if !val.IsNull() || !tftypes.IsDynamicType(val.Type(ctx)) {
+		return val
+	}
+
+	if null, err := tftypes.NullValue(ctx, typ); err == nil {
+		return null
+	}
+
+	return val
+}
+
+// nullOfType returns a null value of the given type, or a dynamic null
+// when the type is dynamic (or missing).
+func nullOfType(ctx context.Context, typ attr.Type) (attr.Value, error) {
+	if typ == nil || tftypes.IsDynamicType(typ) {
+		return basetypes.NewDynamicNull(), nil
+	}
+
+	return tftypes.NullValue(ctx, typ)
+}
+
+// allOfType checks if all the given types are equal to the expected one.
+func allOfType(tys []attr.Type, typ attr.Type) bool {
+	for _, ty := range tys {
+		if !tftypes.TypeEqual(ty, typ) {
+			return false
 		}
-		return nil, err
 	}
 
-	return res, nil
+	return true
+}
+
+// toTfPrimitive converts an exported goja primitive into an attr.Value.
+//
+// The type is only used to type the null values: primitives that do not
+// match it are returned as they are.
+func toTfPrimitive(ctx context.Context, v any, typ attr.Type) (attr.Value, error) {
+	switch p := v.(type) {
+	case nil:
+		return nullOfType(ctx, typ)
+	case bool:
+		return basetypes.NewBoolValue(p), nil
+	case string:
+		return basetypes.NewStringValue(p), nil
+	case int64:
+		return basetypes.NewNumberValue(new(big.Float).SetInt64(p)), nil
+	case float64:
+		// JSON cannot represent these numbers, so they are null
+		if math.IsNaN(p) || math.IsInf(p, 0) {
+			return nullOfType(ctx, typ)
+		}
+
+		return basetypes.NewNumberValue(big.NewFloat(p)), nil
+	case *big.Int:
+		return basetypes.NewNumberValue(new(big.Float).SetInt(p)), nil
+	}
+
+	return nil, fmt.Errorf("%w: %T", ErrUnknownType, v)
+}
+
+// isJSONUndefined checks if a value is one of the values that
+// JSON.stringify serializes as undefined (or skips, within objects).
+func isJSONUndefined(v goja.Value) bool {
+	if v == nil || goja.IsUndefined(v) {
+		return true
+	}
+
+	if _, ok := v.(*goja.Symbol); ok {
+		return true
+	}
+
+	_, ok := goja.AssertFunction(v)
+	return ok
 }
@@ -10,6 +10,11 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 )
 
+func bigInt(s string) *big.Int {
+	i, _ := new(big.Int).SetString(s, 10)
+	return i
+}
+
 func TestToTfValue(t *testing.T) {
 	tests := []struct {
 		Src  string
@@ -67,6 +72,21 @@ func TestToTfValue(t *testing.T) {
 			Src:  `[]`,
 			Want: basetypes.NewTupleValueMust([]attr.Type{}, []attr.Value{}),
 		},
+		{
+			Src: `[true, "a", 1]`,
+			Want: basetypes.NewTupleValueMust(
+				[]attr.Type{
+					basetypes.BoolType{},
+					basetypes.StringType{},
+					basetypes.NumberType{},
+				},
+				[]attr.Value{
+					basetypes.NewBoolValue(true),
+					basetypes.NewStringValue("a"),
+					basetypes.NewNumberValue(big.NewFloat(1)),
+				},
+			),
+		},
 		{
 			Src: `[true]`,
 			Want: basetypes.NewTupleValueMust(
@@ -84,9 +104,13 @@ func TestToTfValue(t *testing.T) {
 		},
 		{
 			Src: `new Date(0)`,
-			// The Date prototype includes a toJSON function which
-			// produces a timestamp string.
-			Want: basetypes.NewStringValue("1970-01-01T00:00:00.000Z"),
+			// Dates are formatted like Terraform's timestamp function
+			// formats them, in UTC and without fractional seconds.
+			Want: basetypes.NewStringValue("1970-01-01T00:00:00Z"),
+		},
+		{
+			Src:  `new Date(NaN)`,
+			Want: basetypes.NewDynamicNull(),
 		},
 		{
 			Src: `JSON`,
@@ -94,6 +118,44 @@ func TestToTfValue(t *testing.T) {
 			// as an empty object after conversion.
 			Want: basetypes.NewObjectValueMust(map[string]attr.Type{}, map[string]attr.Value{}),
 		},
+		{
+			Src:  "123456789012345678901234567890n",
+			Want: basetypes.NewNumberValue(new(big.Float).SetInt(bigInt("123456789012345678901234567890"))),
+		},
+		{
+			Src:  "new Number(12)",
+			Want: basetypes.NewNumberValue(big.NewFloat(12)),
+		},
+		{
+			Src: `({a_b: 1, A_b: "2", "not an identifier": true})`,
+			Want: basetypes.NewObjectValueMust(
+				map[string]attr.Type{
+					"a_b":               basetypes.NumberType{},
+					"A_b":               basetypes.StringType{},
+					"not an identifier": basetypes.BoolType{},
+				},
+				map[string]attr.Value{
+					"a_b":               basetypes.NewNumberValue(big.NewFloat(1)),
+					"A_b":               basetypes.NewStringValue("2"),
+					"not an identifier": basetypes.NewBoolValue(true),
+				},
+			),
+		},
+		{
+			Src: `({a: undefined, b: function () {}, c: null})`,
+			Want: basetypes.NewObjectValueMust(
+				map[string]attr.Type{
+					"c": basetypes.DynamicType{},
+				},
+				map[string]attr.Value{
+					"c": basetypes.NewDynamicNull(),
+				},
+			),
+		},
+		{
+			Src: `(function () { var o = {}; o.self = o; return o; })()`,
+			Err: true,
+		},
 		{
 			Src:  "NaN",
 			Want: basetypes.NewDynamicNull(),
@@ -104,6 +166,18 @@ func TestToTfValue(t *testing.T) {
 			// and our mapping is via JSON and so the result is null.
 			Want: basetypes.NewDynamicNull(),
 		},
+		{
+			Src:  "new Uint8Array([104, 105])",
+			Want: basetypes.NewStringValue("aGk="),
+		},
+		{
+			Src:  "new Uint8Array([0, 104, 105]).subarray(1)",
+			Want: basetypes.NewStringValue("aGk="),
+		},
+		{
+			Src:  "new Uint8Array([104, 105]).buffer",
+			Want: basetypes.NewStringValue("aGk="),
+		},
 	}
 
 	ctx := context.Background()
@@ -134,3 +208,149 @@ func TestToTfValue(t *testing.T) {
 		})
 	}
 }
+
+func TestToTfValueOfType(t *testing.T) {
+	tests := []struct {
+		Src  string
+		Type attr.Type
+		Want attr.Value
+	}{
+		{
+			Src:  "null",
+			Type: basetypes.StringType{},
+			Want: basetypes.NewStringNull(),
+		},
+		{
+			Src:  "NaN",
+			Type: basetypes.NumberType{},
+			Want: basetypes.NewNumberNull(),
+		},
+		{
+			Src:  `["a", null]`,
+			Type: basetypes.ListType{ElemType: basetypes.StringType{}},
+			Want: basetypes.NewListValueMust(basetypes.StringType{}, []attr.Value{
+				basetypes.NewStringValue("a"),
+				basetypes.NewStringNull(),
+			}),
+		},
+		{
+			Src:  `[1, 2]`,
+			Type: basetypes.SetType{ElemType: basetypes.NumberType{}},
+			Want: basetypes.NewSetValueMust(basetypes.NumberType{}, []attr.Value{
+				basetypes.NewNumberValue(big.NewFloat(1)),
+				basetypes.NewNumberValue(big.NewFloat(2)),
+			}),
+		},
+		{
+			Src:  `({a_b: "x", A_b: "y"})`,
+			Type: basetypes.MapType{ElemType: basetypes.StringType{}},
+			Want: basetypes.NewMapValueMust(basetypes.StringType{}, map[string]attr.Value{
+				"a_b": basetypes.NewStringValue("x"),
+				"A_b": basetypes.NewStringValue("y"),
+			}),
+		},
+		{
+			Src: `({tags: ["a"], owner: null})`,
+			Type: basetypes.ObjectType{AttrTypes: map[string]attr.Type{
+				"tags":  basetypes.ListType{ElemType: basetypes.StringType{}},
+				"owner": basetypes.StringType{},
+			}},
+			Want: basetypes.NewObjectValueMust(
+				map[string]attr.Type{
+					"tags":  basetypes.ListType{ElemType: basetypes.StringType{}},
+					"owner": basetypes.StringType{},
+				},
+				map[string]attr.Value{
+					"tags": basetypes.NewListValueMust(basetypes.StringType{}, []attr.Value{
+						basetypes.NewStringValue("a"),
+					}),
+					"owner": basetypes.NewStringNull(),
+				},
+			),
+		},
+		{
+			Src:  `[{a: 1}]`,
+			Type: basetypes.TupleType{ElemTypes: []attr.Type{basetypes.MapType{ElemType: basetypes.NumberType{}}}},
+			Want: basetypes.NewTupleValueMust(
+				[]attr.Type{basetypes.MapType{ElemType: basetypes.NumberType{}}},
+				[]attr.Value{
+					basetypes.NewMapValueMust(basetypes.NumberType{}, map[string]attr.Value{
+						"a": basetypes.NewNumberValue(big.NewFloat(1)),
+					}),
+				},
+			),
+		},
+		{
+			// Values that do not match the type keep their implied type
+			Src:  `["a", 1]`,
+			Type: basetypes.ListType{ElemType: basetypes.StringType{}},
+			Want: basetypes.NewTupleValueMust(
+				[]attr.Type{basetypes.StringType{}, basetypes.NumberType{}},
+				[]attr.Value{
+					basetypes.NewStringValue("a"),
+					basetypes.NewNumberValue(big.NewFloat(1)),
+				},
+			),
+		},
+		{
+			Src:  `new Map([["a", "b"], ["c", null]])`,
+			Type: basetypes.DynamicType{},
+			Want: basetypes.NewMapValueMust(basetypes.StringType{}, map[string]attr.Value{
+				"a": basetypes.NewStringValue("b"),
+				"c": basetypes.NewStringNull(),
+			}),
+		},
+		{
+			Src:  `new Map([["a", "b"], ["c", 1]])`,
+			Type: basetypes.DynamicType{},
+			Want: basetypes.NewObjectValueMust(
+				map[string]attr.Type{"a": basetypes.StringType{}, "c": basetypes.NumberType{}},
+				map[string]attr.Value{"a": basetypes.NewStringValue("b"), "c": basetypes.NewNumberValue(big.NewFloat(1))},
+			),
+		},
+		{
+			Src:  `new Set([1, 2])`,
+			Type: basetypes.DynamicType{},
+			Want: basetypes.NewSetValueMust(basetypes.NumberType{}, []attr.Value{
+				basetypes.NewNumberValue(big.NewFloat(1)),
+				basetypes.NewNumberValue(big.NewFloat(2)),
+			}),
+		},
+		{
+			Src:  `new Set(["a"])`,
+			Type: basetypes.ListType{ElemType: basetypes.StringType{}},
+			Want: basetypes.NewListValueMust(basetypes.StringType{}, []attr.Value{
+				basetypes.NewStringValue("a"),
+			}),
+		},
+		{
+			Src:  `({a: "b"})`,
+			Type: basetypes.StringType{},
+			Want: basetypes.NewObjectValueMust(
+				map[string]attr.Type{"a": basetypes.StringType{}},
+				map[string]attr.Value{"a": basetypes.NewStringValue("b")},
+			),
+		},
+	}
+
+	ctx := context.Background()
+
+	for _, test := range tests {
+		t.Run(test.Src, func(t *testing.T) {
+			js := goja.New()
+			result, err := js.RunString(test.Src)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			got, err := ToTfValueOfType(ctx, result, test.Type, js, Options{})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !test.Want.Equal(got) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}